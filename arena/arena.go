@@ -0,0 +1,239 @@
+package arena
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/trade"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+)
+
+// EquityPoint 是参赛者权益曲线上的一个采样点
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Entrant 是一个参赛模型，拥有独立的模拟账户
+type Entrant struct {
+	Name    string
+	Account *trade.PaperExecutor // 行情来自 Arena 的快照
+	// Cycle 执行一次完整的决策周期，由调用方在 Join 之后设置
+	Cycle func(ctx context.Context)
+
+	equity []EquityPoint
+}
+
+// Arena 让多个模型在同一份行情快照上各自决策:
+// 每一轮只调用一次 market.AssemblePromptData，快照随后分发给所有参赛者的模拟账户，
+// 保证模型之间的比较建立在完全相同的市场条件之上。
+// Arena 本身实现了 collector.StateProvider，作为参赛者模拟账户的行情源。
+type Arena struct {
+	market collector.StateProvider
+	dir    string // 参赛者的账户、持久化状态与权益曲线保存在该目录下，为空时只保存在内存中
+
+	mu       sync.RWMutex
+	snapshot entity.PromptData
+	ready    bool
+	entrants []*Entrant
+}
+
+func New(market collector.StateProvider, dir string) (*Arena, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create arena directory: %w", err)
+		}
+	}
+	return &Arena{market: market, dir: dir}, nil
+}
+
+// Join 为 name 创建一个模拟账户并加入竞技场，已有的账户与权益曲线会从 dir 中恢复
+func (a *Arena) Join(name string, cfg trade.PaperConfig) *Entrant {
+	e := &Entrant{Name: name}
+	cfg.PersistencePath = a.Path(name, "account.json")
+	if path := a.Path(name, "equity.jsonl"); path != "" {
+		equity, err := loadEquity(path)
+		if err != nil {
+			log.Printf("⚠️ [竞技场] 无法读取 %s 的权益曲线: %v", name, err)
+		}
+		e.equity = equity
+	}
+	e.Account = trade.NewPaperExecutor(a, cfg)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entrants = append(a.entrants, e)
+	return e
+}
+
+// Path 返回参赛者 name 的文件路径 (e.g. dir/kimi-k2-thinking-turbo.account.json)，dir 为空时返回空字符串
+func (a *Arena) Path(name, suffix string) string {
+	if a.dir == "" {
+		return ""
+	}
+	safe := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, name)
+	return filepath.Join(a.dir, safe+"."+suffix)
+}
+
+// AssemblePromptData 返回本轮的行情快照，账户与持仓由参赛者的模拟账户替换
+func (a *Arena) AssemblePromptData(_ context.Context) (entity.PromptData, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.ready {
+		return lo.Empty[entity.PromptData](), errors.New("本轮行情快照尚未就绪")
+	}
+	return a.snapshot, nil
+}
+
+func (a *Arena) GetStartingCapital() float64 {
+	return a.market.GetStartingCapital()
+}
+
+// Round 获取一次行情快照，并发执行所有参赛者的决策周期，结束后记录各自的账户价值
+func (a *Arena) Round(ctx context.Context) error {
+	data, err := a.market.AssemblePromptData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to assemble market snapshot: %w", err)
+	}
+	a.mu.Lock()
+	a.snapshot, a.ready = data, true
+	entrants := append([]*Entrant(nil), a.entrants...)
+	a.mu.Unlock()
+
+	log.Printf("🏟️ [竞技场] 行情快照已就绪，%d 个模型开始决策...", len(entrants))
+	var g errgroup.Group
+	for _, e := range entrants {
+		g.Go(func() error {
+			e.Cycle(ctx)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	now := utils.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, e := range entrants {
+		point := EquityPoint{Time: now, Equity: e.Account.AccountValue()}
+		e.equity = append(e.equity, point)
+		if err := appendEquity(a.Path(e.Name, "equity.jsonl"), point); err != nil {
+			log.Printf("⚠️ [竞技场] 无法保存 %s 的权益曲线: %v", e.Name, err)
+		}
+	}
+	return nil
+}
+
+// Standing 是排行榜上的一行，百分比字段以百分数表示 (e.g. 12.5 = 12.5%)
+type Standing struct {
+	Name           string  `json:"name"`
+	Equity         float64 `json:"equity"`
+	ReturnPct      float64 `json:"return_pct"`
+	SharpeRatio    float64 `json:"sharpe_ratio"`
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"`
+	Trades         int     `json:"trades"`
+	WinRate        float64 `json:"win_rate"`
+}
+
+// Leaderboard 按收益率从高到低返回所有参赛者的成绩
+func (a *Arena) Leaderboard() []Standing {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	standings := lo.Map(a.entrants, func(e *Entrant, _ int) Standing {
+		initial := e.Account.GetStartingCapital()
+		equities := append([]float64{initial}, lo.Map(e.equity, func(p EquityPoint, _ int) float64 { return p.Equity })...)
+		trades := e.Account.Trades()
+
+		s := Standing{
+			Name:           e.Name,
+			Equity:         e.Account.AccountValue(),
+			SharpeRatio:    utils.SharpeRatio(equities),
+			MaxDrawdownPct: utils.MaxDrawdown(equities) * 100,
+			Trades:         len(trades),
+		}
+		if initial > 0 {
+			s.ReturnPct = (s.Equity - initial) / initial * 100
+		}
+		if len(trades) > 0 {
+			wins := lo.CountBy(trades, func(t trade.PaperTrade) bool { return t.PnL > 0 })
+			s.WinRate = float64(wins) / float64(len(trades)) * 100
+		}
+		return s
+	})
+	sort.SliceStable(standings, func(i, j int) bool { return standings[i].ReturnPct > standings[j].ReturnPct })
+	return standings
+}
+
+// LogLeaderboard 打印排行榜，并在 dir 不为空时写入 dir/leaderboard.json
+func (a *Arena) LogLeaderboard() {
+	standings := a.Leaderboard()
+	log.Println("----------- 🏆 竞技场排行榜 -----------")
+	for i, s := range standings {
+		log.Printf("%d. %-32s 权益 $%10.2f, 收益率 %7.2f%%, 夏普比率 %7.4f, 最大回撤 %6.2f%%, 交易 %3d 笔, 胜率 %6.2f%%",
+			i+1, s.Name, s.Equity, s.ReturnPct, s.SharpeRatio, s.MaxDrawdownPct, s.Trades, s.WinRate)
+	}
+
+	if a.dir == "" {
+		return
+	}
+	raw, err := json.MarshalIndent(standings, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(a.dir, "leaderboard.json"), raw, 0644)
+	}
+	if err != nil {
+		log.Printf("⚠️ [竞技场] 无法保存排行榜: %v", err)
+	}
+}
+
+func appendEquity(path string, point EquityPoint) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(point)
+}
+
+func loadEquity(path string) ([]EquityPoint, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var points []EquityPoint
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var p EquityPoint
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			continue // 跳过写入中断产生的残行
+		}
+		points = append(points, p)
+	}
+	return points, scanner.Err()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gtoxlili/echoAlpha/arena"
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/cost"
+	"github.com/gtoxlili/echoAlpha/decisionlog"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/gtoxlili/echoAlpha/journal"
+	"github.com/gtoxlili/echoAlpha/trade"
+	"github.com/samber/lo"
+)
+
+// runArena 让多个模型在同一份实时行情上各自用独立的模拟账户交易，每轮结束后输出排行榜
+func runArena(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("arena", flag.ExitOnError)
+	rawModels := fs.String("models", strings.Join(config.App.Arena.Models, ","), "参赛模型，逗号分隔")
+	rounds := fs.Int("rounds", 0, "执行的轮数，0 表示一直运行")
+	fs.Parse(args)
+
+	models := lo.Uniq(lo.Compact(lo.Map(strings.Split(*rawModels, ","), func(m string, _ int) string {
+		return strings.TrimSpace(m)
+	})))
+	if len(models) == 0 {
+		return errors.New("至少需要一个参赛模型 (-models 或 arena.models)")
+	}
+
+	cfg := config.App
+	creds := exchange.Credentials{BaseURL: cfg.Exchange.BaseURL}
+	market := collector.ResolveMarketCollector(cfg.Exchange.Name, cfg.Coins, creds)
+	querier, _ := market.(collector.MarketQuerier)
+	if streamer, ok := market.(collector.Streamer); ok {
+		streamer.Stream(ctx)
+	}
+	a, err := arena.New(market, cfg.Arena.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		entrant := a.Join(model, newPaperConfig(cfg))
+		store := config.NewPersistence(a.Path(model, "persistence.json"))
+		t := &trader{
+			provider:  entrant.Account,
+			market:    querier,
+			store:     store,
+			manager:   trade.NewManager(store),
+			executor:  entrant.Account,
+			risk:      newRiskEngine(),
+			breaker:   newCircuitBreaker(store),
+			journal:   journal.New(a.Path(model, "journal.jsonl"), entrant.Account),
+			ledger:    cost.New(a.Path(model, "usage.jsonl")),
+			decisions: decisionlog.New(a.Path(model, "decisions")),
+		}
+		if err := t.loadAgent(model); err != nil {
+			return fmt.Errorf("%s: %w", model, err)
+		}
+		entrant.Cycle = t.runDecisionCycle
+		log.Printf("🏟️ [竞技场] %s 已入场，初始资金 $%.2f", model, entrant.Account.GetStartingCapital())
+	}
+
+	for round := 1; *rounds == 0 || round <= *rounds; round++ {
+		if err := delay(ctx); err != nil {
+			log.Printf("❌ 主循环延迟错误: %v", err)
+			return nil
+		}
+		log.Printf("🏟️ [竞技场] 第 %d 轮开始", round)
+		if err := a.Round(ctx); err != nil {
+			log.Printf("❌ [竞技场] 错误: %v", err)
+			continue // 非致命错误，等待下一轮
+		}
+		a.LogLeaderboard()
+	}
+	return nil
+}
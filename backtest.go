@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/backtest"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/cost"
+	"github.com/gtoxlili/echoAlpha/decisionlog"
+	"github.com/gtoxlili/echoAlpha/journal"
+	"github.com/gtoxlili/echoAlpha/trade"
+)
+
+// runBacktest 用历史数据驱动与实盘完全相同的决策流程，
+// 组合分析与持仓元数据只保存在内存中，不会影响实盘的持久化文件
+func runBacktest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	dataDir := fs.String("data", "", "历史数据目录")
+	outDir := fs.String("out", "backtest-result", "回测结果 (权益曲线、交易列表与决策审计日志) 输出目录")
+	rawFrom := fs.String("from", "", "回测开始时间 (UTC, 2006-01-02 或 2006-01-02 15:04:05)")
+	rawTo := fs.String("to", "", "回测结束时间 (UTC, 格式同 -from)")
+	fs.Parse(args)
+	if *dataDir == "" {
+		return errors.New("-data is required")
+	}
+
+	from, err := parseBacktestTime(*rawFrom)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to, err := parseBacktestTime(*rawTo)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	log.Printf("🔁 [回测] 正在从 %s 加载历史数据...", *dataDir)
+	engine, err := backtest.New(backtest.Config{
+		DataDir: *dataDir,
+		Coins:   config.App.Coins,
+		From:    from,
+		To:      to,
+		Paper:   newPaperConfig(config.App),
+	})
+	if err != nil {
+		return err
+	}
+
+	store := config.NewPersistence("")
+	t := &trader{
+		provider:  engine,
+		market:    engine.Market(),
+		store:     store,
+		manager:   trade.NewManager(store),
+		executor:  engine,
+		risk:      newRiskEngine(),
+		breaker:   newCircuitBreaker(store),
+		journal:   journal.New("", engine),
+		ledger:    cost.New(""),
+		decisions: decisionlog.New(filepath.Join(*outDir, "decisions")),
+	}
+	if err := t.loadAgent(config.App.Model.Name); err != nil {
+		return err
+	}
+
+	report, err := engine.Run(ctx, t.runDecisionCycle)
+	if err != nil {
+		return err
+	}
+
+	report.Log()
+	cost.Summarize(t.ledger.Query(time.Time{}, time.Time{}), t.journal.Query("", time.Time{}, time.Time{})).Log()
+	if err := report.WriteCSV(*outDir); err != nil {
+		return fmt.Errorf("failed to write backtest report: %w", err)
+	}
+	log.Printf("✅ [回测] 权益曲线与交易列表已写入 %s", *outDir)
+	return nil
+}
+
+// parseEndTime 解析作为上界 (包含) 的时间，只有日期时包含当天全天
+func parseEndTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return parseBacktestTime(raw)
+}
+
+func parseBacktestTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateTime, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, raw)
+}
@@ -0,0 +1,134 @@
+package backtest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/trade"
+	"github.com/gtoxlili/echoAlpha/utils"
+)
+
+type Config struct {
+	DataDir  string
+	Coins    []string
+	From, To time.Time // 为零值时使用数据本身的时间范围
+	Paper    trade.PaperConfig
+}
+
+// Engine 以模拟时钟回放历史数据，撮合交由 trade.PaperExecutor 完成。
+// 它同时实现了 collector.StateProvider 与 trade.Executor，
+// 因此可以原样复用实盘的 数据采集 → AI 分析 → 交易执行 流程。
+type Engine struct {
+	provider *collector.HistoricalProvider
+	paper    *trade.PaperExecutor
+	start    time.Time
+	end      time.Time
+	equity   []EquityPoint
+}
+
+func New(cfg Config) (*Engine, error) {
+	provider, err := collector.NewHistoricalProvider(cfg.DataDir, cfg.Coins)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := provider.Bounds()
+	if !cfg.From.IsZero() && cfg.From.After(start) {
+		start = cfg.From.Truncate(config.App.Market.KlineInterval)
+	}
+	if !cfg.To.IsZero() && cfg.To.Before(end) {
+		end = cfg.To
+	}
+
+	return &Engine{
+		provider: provider,
+		paper:    trade.NewPaperExecutor(provider, cfg.Paper),
+		start:    start,
+		end:      end,
+	}, nil
+}
+
+func (e *Engine) Bounds() (start, end time.Time) {
+	return e.start, e.end
+}
+
+// Market 返回按模拟时钟查询历史行情的数据源，查询结果不会包含模拟时钟之后的数据
+func (e *Engine) Market() collector.MarketQuerier {
+	return e.provider
+}
+
+func (e *Engine) GetStartingCapital() float64 {
+	return e.paper.GetStartingCapital()
+}
+
+func (e *Engine) AssemblePromptData(ctx context.Context) (entity.PromptData, error) {
+	return e.paper.AssemblePromptData(ctx)
+}
+
+func (e *Engine) Order(ctx context.Context, action entity.TradeSignal) error {
+	return e.paper.Order(ctx, action)
+}
+
+func (e *Engine) CloseOrder(ctx context.Context, coin string) error {
+	return e.paper.CloseOrder(ctx, coin)
+}
+
+func (e *Engine) ProtectiveOrders(ctx context.Context, coin string) (entity.ExitPlanData, error) {
+	return e.paper.ProtectiveOrders(ctx, coin)
+}
+
+func (e *Engine) CloseReason(ctx context.Context, coin string, since time.Time) (entity.CloseReason, float64, error) {
+	return e.paper.CloseReason(ctx, coin, since)
+}
+
+func (e *Engine) Settlement(ctx context.Context, coin string, from, to time.Time) (entity.Settlement, error) {
+	return e.paper.Settlement(ctx, coin, from, to)
+}
+
+// Run 从起点开始每个 KlineInterval 推进一次时钟并调用 cycle，
+// 结束后平掉剩余持仓并返回回测报告
+func (e *Engine) Run(ctx context.Context, cycle func(ctx context.Context)) (*Report, error) {
+	utils.SetClock(e.provider.Now)
+	defer utils.SetClock(nil)
+
+	e.provider.Start(e.start)
+	e.equity = e.equity[:0]
+	steps := int(e.end.Sub(e.start)/config.App.Market.KlineInterval) + 1
+	log.Printf("🔁 [回测] 区间 %s → %s, 共 %d 个决策周期", e.start.Format(time.DateTime), e.end.Format(time.DateTime), steps)
+
+	prev := e.start.Add(-config.App.Market.KlineInterval)
+	for now, step := e.start, 1; !now.After(e.end); now, step = now.Add(config.App.Market.KlineInterval), step+1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		e.provider.SetTime(now)
+		// 逐根回放区间内的 K 线，让止盈止损与强平按最高/最低价触发
+		for coin, bars := range e.provider.LastBars(prev) {
+			for _, k := range bars {
+				e.paper.Update(coin, trade.Quote{Time: k.CloseTime, Price: k.Close, High: k.High, Low: k.Low})
+			}
+		}
+		prev = now
+
+		log.Printf("🔁 [回测] 第 %d/%d 步, 模拟时间 %s", step, steps, now.Format(time.DateTime))
+		cycle(ctx)
+
+		e.equity = append(e.equity, EquityPoint{Time: now, Equity: e.paper.AccountValue()})
+	}
+
+	e.paper.CloseAll("end_of_backtest")
+	e.equity = append(e.equity, EquityPoint{Time: e.end, Equity: e.paper.AccountValue()})
+
+	return &Report{
+		Start:          e.start,
+		End:            e.end,
+		InitialBalance: e.paper.GetStartingCapital(),
+		EquityCurve:    e.equity,
+		Trades:         e.paper.Trades(),
+	}, nil
+}
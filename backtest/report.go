@@ -0,0 +1,118 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/trade"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+)
+
+// EquityPoint 是权益曲线上的一个采样点
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+type Report struct {
+	Start          time.Time          `json:"start"`
+	End            time.Time          `json:"end"`
+	InitialBalance float64            `json:"initial_balance"`
+	EquityCurve    []EquityPoint      `json:"equity_curve"`
+	Trades         []trade.PaperTrade `json:"trades"`
+}
+
+func (r *Report) FinalEquity() float64 {
+	if len(r.EquityCurve) == 0 {
+		return r.InitialBalance
+	}
+	return r.EquityCurve[len(r.EquityCurve)-1].Equity
+}
+
+func (r *Report) ReturnPct() float64 {
+	if r.InitialBalance == 0 {
+		return 0
+	}
+	return (r.FinalEquity() - r.InitialBalance) / r.InitialBalance * 100
+}
+
+func (r *Report) equities() []float64 {
+	return lo.Map(r.EquityCurve, func(p EquityPoint, _ int) float64 { return p.Equity })
+}
+
+func (r *Report) SharpeRatio() float64 {
+	return utils.SharpeRatio(r.equities())
+}
+
+func (r *Report) MaxDrawdownPct() float64 {
+	return utils.MaxDrawdown(r.equities()) * 100
+}
+
+func (r *Report) WinRate() float64 {
+	if len(r.Trades) == 0 {
+		return 0
+	}
+	wins := lo.CountBy(r.Trades, func(t trade.PaperTrade) bool { return t.PnL > 0 })
+	return float64(wins) / float64(len(r.Trades)) * 100
+}
+
+// Log 打印回测摘要
+func (r *Report) Log() {
+	log.Println("----------- 回测结果 -----------")
+	log.Printf("... 区间: %s → %s", r.Start.Format(time.DateTime), r.End.Format(time.DateTime))
+	log.Printf("... 初始资金: $%.2f, 最终权益: $%.2f", r.InitialBalance, r.FinalEquity())
+	log.Printf("... 收益率: %.2f%%, 最大回撤: %.2f%%, 夏普比率: %.4f", r.ReturnPct(), r.MaxDrawdownPct(), r.SharpeRatio())
+	log.Printf("... 交易次数: %d, 胜率: %.2f%%", len(r.Trades), r.WinRate())
+}
+
+// WriteCSV 将权益曲线与交易列表分别写入 dir/equity.csv 与 dir/trades.csv
+func (r *Report) WriteCSV(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	equityRows := [][]string{{"time", "equity"}}
+	for _, p := range r.EquityCurve {
+		equityRows = append(equityRows, []string{p.Time.Format(time.RFC3339), formatFloat(p.Equity)})
+	}
+	if err := writeCSV(filepath.Join(dir, "equity.csv"), equityRows); err != nil {
+		return err
+	}
+
+	tradeRows := [][]string{{
+		"coin", "side", "quantity", "leverage", "entry_time", "exit_time",
+		"entry_price", "exit_price", "fee", "funding", "pnl", "reason",
+	}}
+	for _, t := range r.Trades {
+		tradeRows = append(tradeRows, []string{
+			t.Coin, t.Side, formatFloat(t.Quantity), strconv.Itoa(t.Leverage),
+			t.EntryTime.Format(time.RFC3339), t.ExitTime.Format(time.RFC3339),
+			formatFloat(t.EntryPrice), formatFloat(t.ExitPrice), formatFloat(t.Fee), formatFloat(t.Funding), formatFloat(t.PnL), t.Reason,
+		})
+	}
+	return writeCSV(filepath.Join(dir, "trades.csv"), tradeRows)
+}
+
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+)
+
+// command 是一个子命令，args 为子命令名之后的参数
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"run", "run", "启动交易主循环 (省略子命令时的默认行为)", runLoop},
+	{"status", "status", "输出账户价值、持仓 (合并本地元数据) 与交易所上的止损止盈挂单", runStatus},
+	{"close", "close <coin>", "撤销 coin 的挂单并市价平仓", runClose},
+	{"flatten", "flatten", "撤销挂单并市价平掉所有持仓", runFlatten},
+	{"once", "once [-dry-run]", "执行一次决策周期后退出，-dry-run 时只输出决策，不下单也不改写本地状态 (LLM 费用仍记入费用账本)", runOnce},
+	{"prompt", "prompt", "渲染当前的系统提示词与用户提示词，不调用 LLM", runPrompt},
+	{"arena", "arena [-models a,b] [-rounds n]", "多个模型共用同一份实时行情，各自在独立的模拟账户中交易并输出排行榜", runArena},
+	{"backtest", "backtest -data <dir> [-from] [-to] [-out]", "用历史数据回测", runBacktest},
+	{"journal", "journal [-coin] [-from] [-to]", "输出交易日志与汇总", runJournal},
+	{"decision", "decision [-dir] <cycle-id>", "按周期 ID 输出完整的决策审计记录 (行情快照、提示词、模型回答与下单结果)", runDecision},
+	{"cost", "cost [-from] [-to]", "按日、按模型汇总 LLM 费用，并与同期交易净盈亏对比", runCost},
+	{"reset-breaker", "reset-breaker", "请求复位账户熔断器，在下一个决策周期生效 (交易进程运行中也可以使用)", runResetBreaker},
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "用法: echoAlpha [-config <file>] [-paper | -shadow] <子命令> [参数]")
+	fmt.Fprintln(out, "\n子命令:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-44s %s\n", c.usage, c.summary)
+	}
+	fmt.Fprintln(out, "\n全局参数:")
+	flag.PrintDefaults()
+}
+
+// runStatus 输出账户与持仓概况，止损止盈价以交易所上实际存在的挂单为准
+func runStatus(ctx context.Context, args []string) error {
+	flag.NewFlagSet("status", flag.ExitOnError).Parse(args)
+
+	t, err := newTrader(config.App, true)
+	if err != nil {
+		return err
+	}
+	data, err := t.provider.AssemblePromptData(ctx)
+	if err != nil {
+		return fmt.Errorf("无法获取账户数据: %w", err)
+	}
+
+	account := data.Account
+	log.Printf("💰 账户价值 $%.2f, 可用 $%.2f, 收益率 %.2f%%, 夏普比率 %.2f",
+		account.AccountValue, account.CashAvailable, account.ReturnPct*100, account.SharpeRatio)
+	if state := t.store.Breaker; state.Tripped {
+		log.Printf("⛔ 熔断中: %s (触发于 %s)", state.Reason, state.TrippedAt.Format(time.DateTime))
+		if t.store.BreakerResetPending() {
+			log.Println("   ... 🟢 已请求复位，将在下一个决策周期生效")
+		}
+	}
+
+	if len(data.Positions) == 0 {
+		log.Println("📭 当前无持仓。")
+	}
+	for _, p := range data.Positions {
+		side := "long"
+		if p.Quantity < 0 {
+			side = "short"
+		}
+		log.Printf("📌 %s %s %dx 数量 %f, 入场 %.4f, 现价 %.4f, 强平 %.4f, 未实现盈亏 %.4f",
+			p.Symbol, side, p.Leverage, p.Quantity, p.EntryPrice, p.CurrentPrice, p.LiqPrice, p.UnrealizedPNL)
+
+		if orders, err := t.executor.ProtectiveOrders(ctx, p.Symbol); err != nil {
+			log.Printf("   ├─ ⚠️ 无法获取止损止盈挂单: %v", err)
+		} else {
+			log.Printf("   ├─ 挂单: 止损 %s, 止盈 %s", formatTrigger(orders.StopLoss), formatTrigger(orders.ProfitTarget))
+		}
+
+		meta, ok := t.manager.Get(p.Symbol)
+		if !ok {
+			log.Println("   └─ 无本地元数据 (非本机器人开仓)")
+			continue
+		}
+		log.Printf("   ├─ 计划: 止损 %.4f, 止盈 %.4f, 信心 %.2f, 风险 $%.2f, 已持仓 %.0f 分钟",
+			meta.StopLoss, meta.ProfitTarget, meta.Confidence, meta.RiskUSD, utils.Now().Sub(meta.EntryTime).Minutes())
+		log.Printf("   ├─ 失效条件: %s", meta.InvalidationCondition)
+		log.Printf("   └─ 理由: %s", meta.Justification)
+	}
+	return nil
+}
+
+// runClose 平掉指定币种的持仓
+func runClose(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("close", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("用法: close <coin>")
+	}
+	coin := strings.ToUpper(fs.Arg(0))
+
+	t, err := newTrader(config.App, false)
+	if err != nil {
+		return err
+	}
+	if err := t.closePosition(ctx, coin, entity.CloseReasonManual); err != nil {
+		return fmt.Errorf("平仓 %s 失败: %w", coin, err)
+	}
+	log.Printf("✅ [平仓] %s 已平仓。", coin)
+	return nil
+}
+
+// runFlatten 平掉交易所上的所有持仓
+func runFlatten(ctx context.Context, args []string) error {
+	flag.NewFlagSet("flatten", flag.ExitOnError).Parse(args)
+
+	t, err := newTrader(config.App, false)
+	if err != nil {
+		return err
+	}
+	data, err := t.provider.AssemblePromptData(ctx)
+	if err != nil {
+		return fmt.Errorf("无法获取持仓: %w", err)
+	}
+	if len(data.Positions) == 0 {
+		log.Println("📭 当前无持仓。")
+		return nil
+	}
+	if remaining := t.flatten(ctx, data.Positions, entity.CloseReasonManual); len(remaining) > 0 {
+		return fmt.Errorf("%d 个持仓平仓失败", len(remaining))
+	}
+	return nil
+}
+
+// runOnce 立即执行一次决策周期
+func runOnce(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("once", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "只输出决策，不下单，也不改写持久化状态、交易日志与模拟账户")
+	fs.Parse(args)
+
+	t, err := newTrader(config.App, *dryRun)
+	if err != nil {
+		return err
+	}
+	if err := t.loadAgent(config.App.Model.Name); err != nil {
+		return err
+	}
+	t.runDecisionCycle(ctx)
+	return nil
+}
+
+// runPrompt 按与决策周期相同的方式准备数据并输出提示词
+func runPrompt(ctx context.Context, args []string) error {
+	flag.NewFlagSet("prompt", flag.ExitOnError).Parse(args)
+
+	t, err := newTrader(config.App, true)
+	if err != nil {
+		return err
+	}
+	if err := t.loadAgent(config.App.Model.Name); err != nil {
+		return err
+	}
+	data, err := t.provider.AssemblePromptData(ctx)
+	if err != nil {
+		return fmt.Errorf("无法获取市场数据: %w", err)
+	}
+	data.RecentTrades = t.journal.Recent(config.App.Trading.RecentTradesLimit)
+	t.mergeMetadata(data.Positions)
+
+	system, user := t.agent.Prompts(data)
+	fmt.Printf("===== SYSTEM PROMPT =====\n%s\n\n===== USER PROMPT =====\n%s\n", system, user)
+	return nil
+}
+
+func runResetBreaker(_ context.Context, args []string) error {
+	flag.NewFlagSet("reset-breaker", flag.ExitOnError).Parse(args)
+
+	// 交易进程可能正在运行并持有状态文件，只留下复位请求，由它在下一个决策周期处理
+	path := statePaths(config.App).Store
+	if err := config.NewPersistence(path).RequestBreakerReset(); err != nil {
+		return fmt.Errorf("无法写入熔断复位请求: %w", err)
+	}
+	log.Println("🟢 [熔断] 已请求复位，将在下一个决策周期生效 (交易进程未运行时为启动后的第一个决策周期)。")
+	return nil
+}
+
+func formatTrigger(price float64) string {
+	if price == 0 {
+		return "无"
+	}
+	return fmt.Sprintf("%.4f", price)
+}
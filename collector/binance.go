@@ -6,24 +6,20 @@ import (
 	"fmt"
 	"log"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
 	"github.com/adshao/go-binance/v2/futures"
-	"github.com/cinar/indicator"
 	"github.com/gtoxlili/echoAlpha/config"
 	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
 	"github.com/gtoxlili/echoAlpha/utils"
 	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
 )
 
-const (
-	usdtSuffix = "USDT"
-)
-
 type binanceProvider struct {
 	client    *futures.Client
 	coins     []string
@@ -33,15 +29,24 @@ type binanceProvider struct {
 	initialAccountValue     float64
 	historicalAccountValues []float64
 	historicalMu            sync.RWMutex // 用于保护 slice 的读写
+	// marketOnly 为 true 时只采集行情，不访问账户与持仓 (模拟盘无需 API Key)
+	marketOnly bool
+}
+
+func newBinanceMarketProvider(creds exchange.Credentials, coins []string) *binanceProvider {
+	return &binanceProvider{
+		client:     newBinanceClient(exchange.Credentials{BaseURL: creds.BaseURL}),
+		coins:      lo.Map(coins, func(coin string, _ int) string { return exchange.Binance.Symbol(coin) }),
+		createdAt:  time.Now(),
+		marketOnly: true,
+	}
 }
 
-func newBinanceProvider(apiKey, secretKey string, coins []string) *binanceProvider {
+func newBinanceProvider(creds exchange.Credentials, coins []string) *binanceProvider {
 	provider := &binanceProvider{
-		client: binance.NewFuturesClient(apiKey, secretKey),
-		coins: lo.Map(coins, func(coin string, _ int) string {
-			return strings.ToUpper(coin) + usdtSuffix
-		}),
-		historicalAccountValues: make([]float64, 0, config.MaxHistoricalValues),
+		client:                  newBinanceClient(creds),
+		coins:                   lo.Map(coins, func(coin string, _ int) string { return exchange.Binance.Symbol(coin) }),
+		historicalAccountValues: make([]float64, 0, config.App.Market.MaxHistoricalValues),
 	}
 
 	res, err := utils.RetryWithBackoff(func() (*futures.Account, error) {
@@ -62,11 +67,27 @@ func newBinanceProvider(apiKey, secretKey string, coins []string) *binanceProvid
 	return provider
 }
 
+func newBinanceClient(creds exchange.Credentials) *futures.Client {
+	client := binance.NewFuturesClient(creds.APIKey, creds.APISecret)
+	if creds.BaseURL != "" {
+		client.BaseURL = creds.BaseURL
+	}
+	return client
+}
+
 func (b *binanceProvider) GetStartingCapital() float64 {
 	return b.initialAccountValue
 }
 
 func (b *binanceProvider) AssemblePromptData(ctx context.Context) (entity.PromptData, error) {
+	return b.assemblePromptData(ctx, b.fetchCoinData)
+}
+
+// assemblePromptData 用 fetchCoin 获取每个币种的行情，并通过 REST 获取账户与持仓
+func (b *binanceProvider) assemblePromptData(
+	ctx context.Context,
+	fetchCoin func(ctx context.Context, symbol string) (entity.CoinData, error),
+) (entity.PromptData, error) {
 	var (
 		mu          sync.Mutex
 		accountData entity.AccountData
@@ -79,7 +100,7 @@ func (b *binanceProvider) AssemblePromptData(ctx context.Context) (entity.Prompt
 		local := symbol
 		g.Go(func() error {
 			coinData, err := utils.RetryWithBackoff(func() (entity.CoinData, error) {
-				return b.fetchCoinData(gctx, local)
+				return fetchCoin(gctx, local)
 			}, 5)
 			if err != nil {
 				log.Printf("error fetching data for %s: %v", local, err)
@@ -87,39 +108,42 @@ func (b *binanceProvider) AssemblePromptData(ctx context.Context) (entity.Prompt
 			}
 
 			mu.Lock()
-			coinDataMap[strings.TrimSuffix(local, usdtSuffix)] = coinData
+			coinDataMap[exchange.Binance.Coin(local)] = coinData
 			mu.Unlock()
 			return nil
 		})
 	}
 
-	// --- 2. 获取账户数据 ---
-	g.Go(func() error {
-		var err error
-		// 同样使用 RetryWithBackoff
-		accountData, err = utils.RetryWithBackoff(func() (entity.AccountData, error) {
-			return b.fetchAccountData(gctx)
-		}, 5)
+	// 模拟盘只需要行情，账户与持仓由模拟账户提供
+	if !b.marketOnly {
+		// --- 2. 获取账户数据 ---
+		g.Go(func() error {
+			var err error
+			// 同样使用 RetryWithBackoff
+			accountData, err = utils.RetryWithBackoff(func() (entity.AccountData, error) {
+				return b.fetchAccountData(gctx)
+			}, 5)
 
-		if err != nil {
-			log.Printf("error fetching account data: %v", err)
-			return nil // 同上，记录日志但不中断
-		}
-		return nil
-	})
+			if err != nil {
+				log.Printf("error fetching account data: %v", err)
+				return nil // 同上，记录日志但不中断
+			}
+			return nil
+		})
 
-	g.Go(func() error {
-		var err error
-		// 同样使用 RetryWithBackoff
-		positions, err = utils.RetryWithBackoff(func() ([]entity.PositionData, error) {
-			return b.fetchPositionsData(gctx)
-		}, 5)
+		g.Go(func() error {
+			var err error
+			// 同样使用 RetryWithBackoff
+			positions, err = utils.RetryWithBackoff(func() ([]entity.PositionData, error) {
+				return b.fetchPositionsData(gctx)
+			}, 5)
 
-		if err != nil {
-			log.Printf("error fetching positions data: %v", err)
-		}
-		return nil
-	})
+			if err != nil {
+				log.Printf("error fetching positions data: %v", err)
+			}
+			return nil
+		})
+	}
 
 	if err := g.Wait(); err != nil {
 		return lo.Empty[entity.PromptData](), err
@@ -161,54 +185,20 @@ func (b *binanceProvider) fetchCoinData(ctx context.Context, symbol string) (ent
 	})
 
 	g.Go(func() error {
-		interval := fmt.Sprintf("%.0fm", config.KlineInterval.Minutes())
-		_, _, _, close3m, _, err := b.fetchAndParseKlines(gctx, symbol, interval, config.KlineLimit)
+		_, _, _, close3m, _, err := b.fetchAndParseKlines(gctx, symbol, IntervalString(config.App.Market.KlineInterval), config.App.Market.KlineLimit)
 		if err != nil {
 			return fmt.Errorf("failed to fetch 3m klines for %s: %w", symbol, err)
 		}
-
-		ema203m := indicator.Ema(20, close3m)
-		macd3m, _ := indicator.Macd(close3m)
-		_, rsi73m := indicator.RsiPeriod(7, close3m)
-		_, rsi143m := indicator.RsiPeriod(14, close3m)
-
-		data.Intraday.Prices3m = lo.Subset(close3m, -config.SeriesLength, uint(config.SeriesLength))
-		data.Intraday.Ema203m = lo.Subset(ema203m, -config.SeriesLength, uint(config.SeriesLength))
-		data.Intraday.MACD3m = lo.Subset(macd3m, -config.SeriesLength, uint(config.SeriesLength))
-		data.Intraday.Rsi73m = lo.Subset(rsi73m, -config.SeriesLength, uint(config.SeriesLength))
-		data.Intraday.Rsi143m = lo.Subset(rsi143m, -config.SeriesLength, uint(config.SeriesLength))
-
-		data.EMA20 = lo.LastOrEmpty(ema203m)
-		data.MACD = lo.LastOrEmpty(macd3m)
-		data.RSI7 = lo.LastOrEmpty(rsi73m)
-
+		fillIntraday(&data, close3m)
 		return nil
 	})
 
 	g.Go(func() error {
-		interval := fmt.Sprintf("%.0fh", config.KlineIntervalLonger.Hours())
-		_, high4h, low4h, close4h, vol4h, err := b.fetchAndParseKlines(gctx, symbol, interval, config.KlineLimit)
+		_, high4h, low4h, close4h, vol4h, err := b.fetchAndParseKlines(gctx, symbol, IntervalString(config.App.Market.KlineIntervalLonger), config.App.Market.KlineLimit)
 		if err != nil {
 			return fmt.Errorf("failed to fetch 4h klines for %s: %w", symbol, err)
 		}
-
-		ema204h := indicator.Ema(20, close4h)
-		ema504h := indicator.Ema(50, close4h)
-		_, atr34h := indicator.Atr(3, high4h, low4h, close4h)
-		_, atr144h := indicator.Atr(14, high4h, low4h, close4h)
-		macd4h, _ := indicator.Macd(close4h)
-		_, rsi144h := indicator.RsiPeriod(14, close4h)
-
-		data.LongTerm.Ema204h = lo.LastOrEmpty(ema204h)
-		data.LongTerm.Ema504h = lo.LastOrEmpty(ema504h)
-		data.LongTerm.Atr34h = lo.LastOrEmpty(atr34h)
-		data.LongTerm.Atr144h = lo.LastOrEmpty(atr144h)
-		data.LongTerm.VolCurr = lo.LastOrEmpty(vol4h)
-		data.LongTerm.VolAvg = lo.Sum(vol4h) / float64(len(vol4h))
-
-		data.LongTerm.MACD4h = lo.Subset(macd4h, -config.SeriesLength, uint(config.SeriesLength))
-		data.LongTerm.Rsi144h = lo.Subset(rsi144h, -config.SeriesLength, uint(config.SeriesLength))
-
+		fillLongTerm(&data, high4h, low4h, close4h, vol4h)
 		return nil
 	})
 
@@ -278,7 +268,7 @@ func (b *binanceProvider) fetchOIFundingData(ctx context.Context, symbol string)
 	})
 
 	g.Go(func() error {
-		hist, err := b.client.NewOpenInterestStatisticsService().Symbol(symbol).Period(config.OiPeriod).Limit(config.OiLimit).Do(ctx)
+		hist, err := b.client.NewOpenInterestStatisticsService().Symbol(symbol).Period(config.App.Market.OiPeriod).Limit(config.App.Market.OiLimit).Do(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to fetch open interest history: %w", err)
 		}
@@ -354,7 +344,7 @@ func (b *binanceProvider) fetchAccountData(ctx context.Context) (entity.AccountD
 	defer b.historicalMu.Unlock()
 
 	b.historicalAccountValues = append(b.historicalAccountValues, currentValue)
-	if len(b.historicalAccountValues) > config.MaxHistoricalValues {
+	if len(b.historicalAccountValues) > config.App.Market.MaxHistoricalValues {
 		b.historicalAccountValues = b.historicalAccountValues[1:]
 	}
 
@@ -373,38 +363,7 @@ func (b *binanceProvider) fetchAccountData(ctx context.Context) (entity.AccountD
 // calculateSharpeRatio 是一个 binanceProvider 的方法
 // 注意：此函数假定在调用它之前已经获取了 b.historicalMu 的锁！
 func (b *binanceProvider) calculateSharpeRatio() float64 {
-	values := b.historicalAccountValues
-
-	// 至少需要3个数据点才能计算2个回报率，从而计算标准差
-	if len(values) < 3 {
-		return 0.0
-	}
-
-	// 1. 计算回报率序列
-	// (P1-P0)/P0, (P2-P1)/P1, ...
-	returns := make([]float64, len(values)-1)
-	for i := 1; i < len(values); i++ {
-		if values[i-1] == 0 { // 避免除以零
-			returns[i-1] = 0.0
-			continue
-		}
-		returns[i-1] = (values[i] - values[i-1]) / values[i-1]
-	}
-
-	// 2. 计算回报率的平均值
-	avgReturn := utils.Avg(returns)
-
-	// 3. 计算回报率的标准差
-	stdDevReturn := utils.StdDev(returns)
-
-	// 4. 计算夏普比率
-	// Sharpe = (Average Return - Risk-Free Rate) / Standard Deviation
-	// 我们假设 Risk-Free Rate (无风险利率) 为 0，这在短周期交易中很常见
-	if stdDevReturn == 0 {
-		return 0.0 // 避免除以零
-	}
-
-	return avgReturn / stdDevReturn
+	return utils.SharpeRatio(b.historicalAccountValues)
 }
 
 func (b *binanceProvider) fetchPositionsData(ctx context.Context) ([]entity.PositionData, error) {
@@ -431,7 +390,7 @@ func (b *binanceProvider) fetchPositionsData(ctx context.Context) ([]entity.Posi
 		notional, _ := strconv.ParseFloat(p.Notional, 64)
 
 		positions = append(positions, entity.PositionData{
-			Symbol:        strings.TrimSuffix(p.Symbol, usdtSuffix), // 移除USDT后缀, 与 coinDataMap 统一
+			Symbol:        exchange.Binance.Coin(p.Symbol), // 移除USDT后缀, 与 coinDataMap 统一
 			Quantity:      quantity,
 			EntryPrice:    entryPrice,
 			LiqPrice:      liqPrice,
@@ -443,3 +402,92 @@ func (b *binanceProvider) fetchPositionsData(ctx context.Context) ([]entity.Posi
 
 	return positions, nil
 }
+
+// binanceDepthLimits 是盘口接口支持的档数
+var binanceDepthLimits = []int{5, 10, 20, 50, 100, 500, 1000}
+
+func (b *binanceProvider) Klines(ctx context.Context, coin string, interval time.Duration, limit int) ([]Kline, error) {
+	res, err := b.client.NewKlinesService().Symbol(exchange.Binance.Symbol(coin)).Interval(IntervalString(interval)).Limit(limit).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s klines for %s: %w", IntervalString(interval), coin, err)
+	}
+	return lo.Map(res, func(k *futures.Kline, _ int) Kline {
+		return Kline{
+			OpenTime:  time.UnixMilli(k.OpenTime),
+			CloseTime: time.UnixMilli(k.CloseTime),
+			Open:      parseFloat(k.Open),
+			High:      parseFloat(k.High),
+			Low:       parseFloat(k.Low),
+			Close:     parseFloat(k.Close),
+			Volume:    parseFloat(k.Volume),
+		}
+	}), nil
+}
+
+func (b *binanceProvider) FundingHistory(ctx context.Context, coin string, limit int) ([]FundingRate, error) {
+	res, err := b.client.NewFundingRateService().Symbol(exchange.Binance.Symbol(coin)).Limit(limit).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch funding history for %s: %w", coin, err)
+	}
+	return lo.Map(res, func(r *futures.FundingRate, _ int) FundingRate {
+		return FundingRate{Time: time.UnixMilli(r.FundingTime), Rate: parseFloat(r.FundingRate)}
+	}), nil
+}
+
+func (b *binanceProvider) OrderBook(ctx context.Context, coin string, depth int) (OrderBook, error) {
+	// 只能请求固定的档数，取不小于 depth 的最小值后再截断
+	limit := lo.FindOrElse(binanceDepthLimits, binanceDepthLimits[len(binanceDepthLimits)-1], func(l int) bool { return l >= depth })
+	res, err := b.client.NewDepthService().Symbol(exchange.Binance.Symbol(coin)).Limit(limit).Do(ctx)
+	if err != nil {
+		return OrderBook{}, fmt.Errorf("failed to fetch order book for %s: %w", coin, err)
+	}
+	level := func(p common.PriceLevel, _ int) PriceLevel {
+		return PriceLevel{Price: parseFloat(p.Price), Quantity: parseFloat(p.Quantity)}
+	}
+	return OrderBook{
+		Bids: lo.Map(res.Bids[:min(depth, len(res.Bids))], level),
+		Asks: lo.Map(res.Asks[:min(depth, len(res.Asks))], level),
+	}, nil
+}
+
+func (b *binanceProvider) Ticker(ctx context.Context, coin string) (Ticker, error) {
+	var ticker Ticker
+	symbol := exchange.Binance.Symbol(coin)
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		price, err := b.fetchCurrentPrice(gctx, symbol)
+		if err != nil {
+			return fmt.Errorf("failed to fetch current price for %s: %w", coin, err)
+		}
+		ticker.Price = price
+		return nil
+	})
+	g.Go(func() error {
+		res, err := b.client.NewPremiumIndexService().Symbol(symbol).Do(gctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch premium index for %s: %w", coin, err)
+		}
+		if index, ok := lo.Find(res, func(r *futures.PremiumIndex) bool { return r.Symbol == symbol }); ok {
+			ticker.FundingRate = parseFloat(index.LastFundingRate)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		res, err := b.client.NewGetOpenInterestService().Symbol(symbol).Do(gctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch open interest for %s: %w", coin, err)
+		}
+		ticker.OpenInterest = parseFloat(res.OpenInterest)
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return Ticker{}, err
+	}
+	return ticker, nil
+}
+
+// parseFloat 解析交易所以字符串返回的数值，格式错误时为 0
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
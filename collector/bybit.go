@@ -0,0 +1,295 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+)
+
+// bybitProvider 通过 Bybit v5 API 采集 U 本位永续合约的行情、账户与持仓
+type bybitProvider struct {
+	client    *exchange.BybitClient
+	coins     []string // 交易对 (e.g. "BTCUSDT")
+	createdAt time.Time
+
+	initialAccountValue     float64
+	historicalAccountValues []float64
+	historicalMu            sync.RWMutex
+	// marketOnly 为 true 时只采集行情，不访问账户与持仓 (模拟盘无需 API Key)
+	marketOnly bool
+}
+
+func newBybitMarketProvider(creds exchange.Credentials, coins []string) *bybitProvider {
+	return &bybitProvider{
+		client:     exchange.NewBybitClient(exchange.Credentials{BaseURL: creds.BaseURL}),
+		coins:      lo.Map(coins, func(coin string, _ int) string { return exchange.Bybit.Symbol(coin) }),
+		createdAt:  time.Now(),
+		marketOnly: true,
+	}
+}
+
+func newBybitProvider(creds exchange.Credentials, coins []string) *bybitProvider {
+	provider := &bybitProvider{
+		client:                  exchange.NewBybitClient(creds),
+		coins:                   lo.Map(coins, func(coin string, _ int) string { return exchange.Bybit.Symbol(coin) }),
+		historicalAccountValues: make([]float64, 0, config.App.Market.MaxHistoricalValues),
+	}
+
+	wallet, err := utils.RetryWithBackoff(func() (exchange.BybitWallet, error) {
+		return provider.client.Wallet(context.Background())
+	}, 3)
+	if err != nil {
+		panic(err)
+	}
+
+	initialAmount := float64(wallet.TotalEquity)
+	provider.historicalAccountValues = append(provider.historicalAccountValues, initialAmount)
+	provider.initialAccountValue = initialAmount
+	provider.createdAt = time.Now().Truncate(3 * time.Minute).Add(3 * time.Minute)
+
+	return provider
+}
+
+func (b *bybitProvider) GetStartingCapital() float64 {
+	return b.initialAccountValue
+}
+
+func (b *bybitProvider) AssemblePromptData(ctx context.Context) (entity.PromptData, error) {
+	var (
+		mu          sync.Mutex
+		accountData entity.AccountData
+		coinDataMap = make(map[string]entity.CoinData, len(b.coins))
+		positions   []entity.PositionData
+		g, gctx     = errgroup.WithContext(ctx)
+	)
+
+	for _, symbol := range b.coins {
+		g.Go(func() error {
+			coinData, err := utils.RetryWithBackoff(func() (entity.CoinData, error) {
+				return b.fetchCoinData(gctx, symbol)
+			}, 5)
+			if err != nil {
+				log.Printf("error fetching data for %s: %v", symbol, err)
+				return nil
+			}
+
+			mu.Lock()
+			coinDataMap[exchange.Bybit.Coin(symbol)] = coinData
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	// 模拟盘只需要行情，账户与持仓由模拟账户提供
+	if !b.marketOnly {
+		g.Go(func() error {
+			var err error
+			accountData, err = utils.RetryWithBackoff(func() (entity.AccountData, error) {
+				return b.fetchAccountData(gctx)
+			}, 5)
+			if err != nil {
+				log.Printf("error fetching account data: %v", err)
+			}
+			return nil
+		})
+
+		g.Go(func() error {
+			var err error
+			positions, err = utils.RetryWithBackoff(func() ([]entity.PositionData, error) {
+				return b.fetchPositionsData(gctx)
+			}, 5)
+			if err != nil {
+				log.Printf("error fetching positions data: %v", err)
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return lo.Empty[entity.PromptData](), err
+	}
+
+	return entity.PromptData{
+		MinutesElapsed: time.Since(b.createdAt).Minutes(),
+		Coins:          coinDataMap,
+		Account:        accountData,
+		Positions: lo.Map(positions, func(p entity.PositionData, _ int) entity.PositionData {
+			if coinData, exists := coinDataMap[p.Symbol]; exists {
+				p.CurrentPrice = coinData.Price
+			}
+			return p
+		}),
+	}, nil
+}
+
+func (b *bybitProvider) fetchCoinData(ctx context.Context, symbol string) (entity.CoinData, error) {
+	var data entity.CoinData
+	var g, gctx = errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		ticker, err := b.client.Ticker(gctx, symbol)
+		if err != nil {
+			return fmt.Errorf("failed to fetch ticker for %s: %w", symbol, err)
+		}
+		data.Price = float64(ticker.LastPrice)
+		data.FundRate = ticker.FundingRate
+		data.OILatest = float64(ticker.OpenInterest)
+		return nil
+	})
+
+	g.Go(func() error {
+		period, err := time.ParseDuration(config.App.Market.OiPeriod)
+		if err != nil {
+			return fmt.Errorf("invalid OiPeriod %q: %w", config.App.Market.OiPeriod, err)
+		}
+		hist, err := b.client.OpenInterestHistory(gctx, symbol, period, config.App.Market.OiLimit)
+		if err != nil {
+			return fmt.Errorf("failed to fetch open interest history for %s: %w", symbol, err)
+		}
+		if len(hist) > 0 {
+			data.OIAvg = lo.Sum(hist) / float64(len(hist))
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		klines, err := b.client.Klines(gctx, symbol, config.App.Market.KlineInterval, config.App.Market.KlineLimit)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s klines for %s: %w", config.App.Market.KlineInterval, symbol, err)
+		}
+		fillIntraday(&data, lo.Map(klines, func(k exchange.BybitKline, _ int) float64 { return k.Close }))
+		return nil
+	})
+
+	g.Go(func() error {
+		klines, err := b.client.Klines(gctx, symbol, config.App.Market.KlineIntervalLonger, config.App.Market.KlineLimit)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s klines for %s: %w", config.App.Market.KlineIntervalLonger, symbol, err)
+		}
+		fillLongTerm(&data,
+			lo.Map(klines, func(k exchange.BybitKline, _ int) float64 { return k.High }),
+			lo.Map(klines, func(k exchange.BybitKline, _ int) float64 { return k.Low }),
+			lo.Map(klines, func(k exchange.BybitKline, _ int) float64 { return k.Close }),
+			lo.Map(klines, func(k exchange.BybitKline, _ int) float64 { return k.Volume }),
+		)
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return lo.Empty[entity.CoinData](), err
+	}
+	return data, nil
+}
+
+func (b *bybitProvider) fetchAccountData(ctx context.Context) (entity.AccountData, error) {
+	wallet, err := b.client.Wallet(ctx)
+	if err != nil {
+		return lo.Empty[entity.AccountData](), fmt.Errorf("failed to fetch wallet balance: %w", err)
+	}
+
+	currentValue := float64(wallet.TotalEquity)
+	data := entity.AccountData{
+		AccountValue:  currentValue,
+		CashAvailable: float64(wallet.TotalAvailableBalance),
+	}
+
+	b.historicalMu.Lock()
+	defer b.historicalMu.Unlock()
+
+	b.historicalAccountValues = append(b.historicalAccountValues, currentValue)
+	if len(b.historicalAccountValues) > config.App.Market.MaxHistoricalValues {
+		b.historicalAccountValues = b.historicalAccountValues[1:]
+	}
+	if b.initialAccountValue > 0 {
+		data.ReturnPct = (currentValue - b.initialAccountValue) / b.initialAccountValue
+	}
+	data.SharpeRatio = utils.SharpeRatio(b.historicalAccountValues)
+
+	return data, nil
+}
+
+func (b *bybitProvider) fetchPositionsData(ctx context.Context) ([]entity.PositionData, error) {
+	res, err := b.client.Positions(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch positions: %w", err)
+	}
+
+	positions := make([]entity.PositionData, 0)
+	for _, p := range res {
+		if p.Size == 0 {
+			continue
+		}
+		// Bybit 的 size 总是正数，方向由 side 决定；统一为 Binance 的正多负空
+		quantity := lo.Ternary(p.Side == "Sell", -float64(p.Size), float64(p.Size))
+		positions = append(positions, entity.PositionData{
+			Symbol:        exchange.Bybit.Coin(p.Symbol),
+			Quantity:      quantity,
+			EntryPrice:    float64(p.AvgPrice),
+			LiqPrice:      float64(p.LiqPrice),
+			UnrealizedPNL: float64(p.UnrealisedPnl),
+			Leverage:      int(p.Leverage),
+			NotionalUSD:   lo.Ternary(quantity < 0, -float64(p.PositionValue), float64(p.PositionValue)),
+		})
+	}
+	return positions, nil
+}
+
+func (b *bybitProvider) Klines(ctx context.Context, coin string, interval time.Duration, limit int) ([]Kline, error) {
+	res, err := b.client.Klines(ctx, exchange.Bybit.Symbol(coin), interval, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s klines for %s: %w", IntervalString(interval), coin, err)
+	}
+	return lo.Map(res, func(k exchange.BybitKline, _ int) Kline {
+		return Kline{
+			OpenTime:  k.Start,
+			CloseTime: k.Start.Add(interval - time.Millisecond),
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+		}
+	}), nil
+}
+
+func (b *bybitProvider) FundingHistory(ctx context.Context, coin string, limit int) ([]FundingRate, error) {
+	res, err := b.client.FundingHistory(ctx, exchange.Bybit.Symbol(coin), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch funding history for %s: %w", coin, err)
+	}
+	return lo.Map(res, func(r exchange.BybitFundingRate, _ int) FundingRate {
+		return FundingRate{Time: r.Time, Rate: r.Rate}
+	}), nil
+}
+
+func (b *bybitProvider) OrderBook(ctx context.Context, coin string, depth int) (OrderBook, error) {
+	res, err := b.client.OrderBook(ctx, exchange.Bybit.Symbol(coin), depth)
+	if err != nil {
+		return OrderBook{}, fmt.Errorf("failed to fetch order book for %s: %w", coin, err)
+	}
+	level := func(p [2]exchange.Number, _ int) PriceLevel {
+		return PriceLevel{Price: float64(p[0]), Quantity: float64(p[1])}
+	}
+	return OrderBook{Bids: lo.Map(res.Bids, level), Asks: lo.Map(res.Asks, level)}, nil
+}
+
+func (b *bybitProvider) Ticker(ctx context.Context, coin string) (Ticker, error) {
+	res, err := b.client.Ticker(ctx, exchange.Bybit.Symbol(coin))
+	if err != nil {
+		return Ticker{}, fmt.Errorf("failed to fetch ticker for %s: %w", coin, err)
+	}
+	return Ticker{
+		Price:        float64(res.LastPrice),
+		FundingRate:  parseFloat(res.FundingRate),
+		OpenInterest: float64(res.OpenInterest),
+	}, nil
+}
@@ -0,0 +1,408 @@
+package collector
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/samber/lo"
+)
+
+// warmupBars 是回测开始前每条 K 线序列至少需要的已收盘根数 (EMA50 需要足够的预热)
+const warmupBars = 50
+
+// Kline 是一根已解析的 K 线
+type Kline struct {
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// timedValue 是资金费率 / 持仓量等按时间排列的单值序列
+type timedValue struct {
+	Time  time.Time
+	Value float64
+}
+
+type coinHistory struct {
+	short   []Kline // KlineInterval 周期
+	long    []Kline // KlineIntervalLonger 周期
+	funding []timedValue
+	oi      []timedValue
+}
+
+// HistoricalProvider 从本地文件读取历史 K 线、资金费率与持仓量，
+// 并在模拟时钟驱动下按时间点还原出与实盘一致的 PromptData。
+//
+// 目录结构与 data.binance.vision 的归档文件名保持一致，可直接解压使用:
+//
+//	<dir>/BTCUSDT-5m-*.csv           短周期 K 线
+//	<dir>/BTCUSDT-4h-*.csv           长周期 K 线
+//	<dir>/BTCUSDT-fundingRate-*.csv  资金费率 (可选)
+//	<dir>/BTCUSDT-metrics-*.csv      持仓量 (可选)
+//
+// 账户与持仓字段留空，由模拟撮合账户负责填充。
+type HistoricalProvider struct {
+	coins   []string
+	history map[string]*coinHistory // key: coin (e.g. "BTC")
+
+	mu      sync.RWMutex
+	startAt time.Time
+	now     time.Time
+}
+
+func NewHistoricalProvider(dir string, coins []string) (*HistoricalProvider, error) {
+	hp := &HistoricalProvider{
+		coins:   lo.Map(coins, func(coin string, _ int) string { return strings.ToUpper(coin) }),
+		history: make(map[string]*coinHistory, len(coins)),
+	}
+
+	shortInterval := IntervalString(config.App.Market.KlineInterval)
+	longInterval := IntervalString(config.App.Market.KlineIntervalLonger)
+
+	for _, coin := range hp.coins {
+		symbol := exchange.Binance.Symbol(coin) // 历史数据使用 data.binance.vision 的文件命名
+		h := &coinHistory{}
+
+		var err error
+		if h.short, err = loadKlines(dir, symbol, shortInterval, config.App.Market.KlineInterval); err != nil {
+			return nil, err
+		}
+		if h.long, err = loadKlines(dir, symbol, longInterval, config.App.Market.KlineIntervalLonger); err != nil {
+			return nil, err
+		}
+		if len(h.short) == 0 || len(h.long) == 0 {
+			return nil, fmt.Errorf("no %s/%s klines found for %s in %s", shortInterval, longInterval, symbol, dir)
+		}
+		// 资金费率与持仓量缺失时不影响回测，只是对应字段为空
+		if h.funding, err = loadTimedValues(dir, symbol, "fundingRate", 0, 2); err != nil {
+			return nil, err
+		}
+		if h.oi, err = loadTimedValues(dir, symbol, "metrics", 0, 2); err != nil {
+			return nil, err
+		}
+		hp.history[coin] = h
+	}
+
+	start, _ := hp.Bounds()
+	hp.startAt, hp.now = start, start
+	return hp, nil
+}
+
+// Bounds 返回所有币种都具备足够预热数据的最早时间与数据结束时间
+func (hp *HistoricalProvider) Bounds() (start, end time.Time) {
+	for i, coin := range hp.coins {
+		h := hp.history[coin]
+		s := lo.Latest(
+			h.short[min(warmupBars, len(h.short)-1)].CloseTime,
+			h.long[min(warmupBars, len(h.long)-1)].CloseTime,
+		)
+		e := h.short[len(h.short)-1].CloseTime
+		if i == 0 || s.After(start) {
+			start = s
+		}
+		if i == 0 || e.Before(end) {
+			end = e
+		}
+	}
+	return start.Truncate(config.App.Market.KlineInterval), end
+}
+
+// Start 设置回测起点，MinutesElapsed 从该时间开始计算
+func (hp *HistoricalProvider) Start(t time.Time) {
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	hp.startAt, hp.now = t, t
+}
+
+// SetTime 推进模拟时钟，之后的数据读取只会看到该时间点之前已收盘的 K 线
+func (hp *HistoricalProvider) SetTime(t time.Time) {
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	hp.now = t
+}
+
+func (hp *HistoricalProvider) Now() time.Time {
+	hp.mu.RLock()
+	defer hp.mu.RUnlock()
+	return hp.now
+}
+
+// LastBars 返回 (after, now] 区间内各币种已收盘的短周期 K 线，用于判断区间内是否触发止盈止损
+func (hp *HistoricalProvider) LastBars(after time.Time) map[string][]Kline {
+	now := hp.Now()
+	bars := make(map[string][]Kline, len(hp.coins))
+	for _, coin := range hp.coins {
+		short := hp.history[coin].short
+		from := closedUntil(short, after)
+		to := closedUntil(short, now)
+		if from < to {
+			bars[coin] = short[from:to]
+		}
+	}
+	return bars
+}
+
+// GetStartingCapital 历史数据本身不包含账户信息，由模拟账户提供
+func (hp *HistoricalProvider) GetStartingCapital() float64 {
+	return 0
+}
+
+func (hp *HistoricalProvider) AssemblePromptData(ctx context.Context) (entity.PromptData, error) {
+	hp.mu.RLock()
+	now, startAt := hp.now, hp.startAt
+	hp.mu.RUnlock()
+
+	coinDataMap := make(map[string]entity.CoinData, len(hp.coins))
+	for _, coin := range hp.coins {
+		if err := ctx.Err(); err != nil {
+			return lo.Empty[entity.PromptData](), err
+		}
+		data, ok := hp.coinDataAt(coin, now)
+		if !ok {
+			continue
+		}
+		coinDataMap[coin] = data
+	}
+	if len(coinDataMap) == 0 {
+		return lo.Empty[entity.PromptData](), fmt.Errorf("no historical data available at %s", now.Format(time.RFC3339))
+	}
+
+	return entity.PromptData{
+		MinutesElapsed: now.Sub(startAt).Minutes(),
+		Coins:          coinDataMap,
+	}, nil
+}
+
+func (hp *HistoricalProvider) coinDataAt(coin string, now time.Time) (entity.CoinData, bool) {
+	h := hp.history[coin]
+	shortEnd := closedUntil(h.short, now)
+	longEnd := closedUntil(h.long, now)
+	if shortEnd == 0 || longEnd == 0 {
+		return lo.Empty[entity.CoinData](), false
+	}
+	short := h.short[max(0, shortEnd-config.App.Market.KlineLimit):shortEnd]
+	long := h.long[max(0, longEnd-config.App.Market.KlineLimit):longEnd]
+
+	var data entity.CoinData
+	data.Price = short[len(short)-1].Close
+	fillIntraday(&data, lo.Map(short, func(k Kline, _ int) float64 { return k.Close }))
+	fillLongTerm(&data,
+		lo.Map(long, func(k Kline, _ int) float64 { return k.High }),
+		lo.Map(long, func(k Kline, _ int) float64 { return k.Low }),
+		lo.Map(long, func(k Kline, _ int) float64 { return k.Close }),
+		lo.Map(long, func(k Kline, _ int) float64 { return k.Volume }),
+	)
+
+	if idx := valuesUntil(h.funding, now); idx > 0 {
+		data.FundRate = strconv.FormatFloat(h.funding[idx-1].Value, 'f', -1, 64)
+	}
+	if idx := valuesUntil(h.oi, now); idx > 0 {
+		window := h.oi[max(0, idx-config.App.Market.OiLimit):idx]
+		data.OILatest = window[len(window)-1].Value
+		data.OIAvg = lo.SumBy(window, func(v timedValue) float64 { return v.Value }) / float64(len(window))
+	}
+	return data, true
+}
+
+// Klines 返回模拟时钟之前已收盘的 K 线，不会泄露未来的数据。
+// interval 需为已加载的短周期或长周期的整数倍，非整数倍时由较大的一个合成，最后一根可能尚未走完。
+func (hp *HistoricalProvider) Klines(ctx context.Context, coin string, interval time.Duration, limit int) ([]Kline, error) {
+	h, ok := hp.history[strings.ToUpper(coin)]
+	if !ok {
+		return nil, fmt.Errorf("no historical data for %s", coin)
+	}
+	base, period := h.long, config.App.Market.KlineIntervalLonger
+	if interval%period != 0 {
+		base, period = h.short, config.App.Market.KlineInterval
+	}
+	if interval%period != 0 {
+		return nil, fmt.Errorf("interval %s is not a multiple of the loaded %s/%s klines", IntervalString(interval),
+			IntervalString(config.App.Market.KlineInterval), IntervalString(config.App.Market.KlineIntervalLonger))
+	}
+	base = base[:closedUntil(base, hp.Now())]
+
+	var klines []Kline
+	for _, k := range base {
+		openTime := k.OpenTime.Truncate(interval)
+		if n := len(klines); n > 0 && klines[n-1].OpenTime.Equal(openTime) {
+			last := &klines[n-1]
+			last.High, last.Low = max(last.High, k.High), min(last.Low, k.Low)
+			last.Close, last.CloseTime = k.Close, k.CloseTime
+			last.Volume += k.Volume
+			continue
+		}
+		k.OpenTime = openTime
+		klines = append(klines, k)
+	}
+	return klines[max(0, len(klines)-limit):], nil
+}
+
+// FundingHistory 返回模拟时钟之前已结算的资金费率
+func (hp *HistoricalProvider) FundingHistory(ctx context.Context, coin string, limit int) ([]FundingRate, error) {
+	h, ok := hp.history[strings.ToUpper(coin)]
+	if !ok {
+		return nil, fmt.Errorf("no historical data for %s", coin)
+	}
+	end := valuesUntil(h.funding, hp.Now())
+	return lo.Map(h.funding[max(0, end-limit):end], func(v timedValue, _ int) FundingRate {
+		return FundingRate{Time: v.Time, Rate: v.Value}
+	}), nil
+}
+
+// OrderBook 历史归档不包含盘口数据
+func (hp *HistoricalProvider) OrderBook(ctx context.Context, coin string, depth int) (OrderBook, error) {
+	return OrderBook{}, errors.New("order book is not available in historical data")
+}
+
+// Ticker 返回模拟时钟处最近一根已收盘短周期 K 线的收盘价、最近一次结算的资金费率与持仓量
+func (hp *HistoricalProvider) Ticker(ctx context.Context, coin string) (Ticker, error) {
+	h, ok := hp.history[strings.ToUpper(coin)]
+	if !ok {
+		return Ticker{}, fmt.Errorf("no historical data for %s", coin)
+	}
+	now := hp.Now()
+	end := closedUntil(h.short, now)
+	if end == 0 {
+		return Ticker{}, fmt.Errorf("no historical data for %s at %s", coin, now.Format(time.RFC3339))
+	}
+	ticker := Ticker{Price: h.short[end-1].Close}
+	if idx := valuesUntil(h.funding, now); idx > 0 {
+		ticker.FundingRate = h.funding[idx-1].Value
+	}
+	if idx := valuesUntil(h.oi, now); idx > 0 {
+		ticker.OpenInterest = h.oi[idx-1].Value
+	}
+	return ticker, nil
+}
+
+// closedUntil 返回 klines 中收盘时间不晚于 now 的根数
+func closedUntil(klines []Kline, now time.Time) int {
+	return sort.Search(len(klines), func(i int) bool { return klines[i].CloseTime.After(now) })
+}
+
+// valuesUntil 返回 values 中时间不晚于 now 的个数
+func valuesUntil(values []timedValue, now time.Time) int {
+	return sort.Search(len(values), func(i int) bool { return values[i].Time.After(now) })
+}
+
+// loadKlines 读取 <dir>/<symbol>-<interval>*.csv 并按开盘时间去重排序
+// 列格式: open_time,open,high,low,close,volume,... (与 Binance 归档一致，表头可选)
+func loadKlines(dir, symbol, interval string, period time.Duration) ([]Kline, error) {
+	rows, err := readCSVRows(filepath.Join(dir, fmt.Sprintf("%s-%s*.csv", symbol, interval)))
+	if err != nil {
+		return nil, err
+	}
+
+	byTime := make(map[int64]Kline, len(rows))
+	for _, row := range rows {
+		if len(row) < 6 {
+			continue
+		}
+		openTime, err := parseTimestamp(row[0])
+		if err != nil {
+			continue // 表头或脏数据
+		}
+		var values [5]float64
+		for i := range values {
+			values[i], _ = strconv.ParseFloat(row[i+1], 64)
+		}
+		byTime[openTime.UnixMilli()] = Kline{
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(period),
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+		}
+	}
+
+	klines := lo.Values(byTime)
+	sort.Slice(klines, func(i, j int) bool { return klines[i].OpenTime.Before(klines[j].OpenTime) })
+	return klines, nil
+}
+
+// loadTimedValues 读取 <dir>/<symbol>-<kind>*.csv 中的 (时间, 数值) 两列
+func loadTimedValues(dir, symbol, kind string, timeCol, valueCol int) ([]timedValue, error) {
+	rows, err := readCSVRows(filepath.Join(dir, fmt.Sprintf("%s-%s*.csv", symbol, kind)))
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]timedValue, 0, len(rows))
+	for _, row := range rows {
+		if len(row) <= max(timeCol, valueCol) {
+			continue
+		}
+		t, err := parseTimestamp(row[timeCol])
+		if err != nil {
+			continue
+		}
+		v, err := strconv.ParseFloat(row[valueCol], 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, timedValue{Time: t, Value: v})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Time.Before(values[j].Time) })
+	return lo.UniqBy(values, func(v timedValue) int64 { return v.Time.UnixMilli() }), nil
+}
+
+func readCSVRows(pattern string) ([][]string, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		for {
+			row, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			rows = append(rows, row)
+		}
+		file.Close()
+	}
+	return rows, nil
+}
+
+// parseTimestamp 兼容毫秒/微秒时间戳与 "2006-01-02 15:04:05" 两种写法 (UTC)
+func parseTimestamp(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if ts, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if ts > 1e15 { // 2025 年起 Binance 现货归档改用微秒
+			return time.UnixMicro(ts).UTC(), nil
+		}
+		return time.UnixMilli(ts).UTC(), nil
+	}
+	return time.Parse(time.DateTime, raw)
+}
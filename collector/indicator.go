@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cinar/indicator"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// fillIntraday 基于短周期收盘价序列计算日内指标，并写入 data
+func fillIntraday(data *entity.CoinData, close3m []float64) {
+	ema203m := indicator.Ema(20, close3m)
+	macd3m, _ := indicator.Macd(close3m)
+	_, rsi73m := indicator.RsiPeriod(7, close3m)
+	_, rsi143m := indicator.RsiPeriod(14, close3m)
+
+	data.Intraday.Prices3m = lo.Subset(close3m, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+	data.Intraday.Ema203m = lo.Subset(ema203m, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+	data.Intraday.MACD3m = lo.Subset(macd3m, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+	data.Intraday.Rsi73m = lo.Subset(rsi73m, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+	data.Intraday.Rsi143m = lo.Subset(rsi143m, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+
+	data.EMA20 = lo.LastOrEmpty(ema203m)
+	data.MACD = lo.LastOrEmpty(macd3m)
+	data.RSI7 = lo.LastOrEmpty(rsi73m)
+}
+
+// fillLongTerm 基于长周期 K 线计算趋势背景指标，并写入 data
+func fillLongTerm(data *entity.CoinData, high4h, low4h, close4h, vol4h []float64) {
+	ema204h := indicator.Ema(20, close4h)
+	ema504h := indicator.Ema(50, close4h)
+	_, atr34h := indicator.Atr(3, high4h, low4h, close4h)
+	_, atr144h := indicator.Atr(14, high4h, low4h, close4h)
+	macd4h, _ := indicator.Macd(close4h)
+	_, rsi144h := indicator.RsiPeriod(14, close4h)
+
+	data.LongTerm.Ema204h = lo.LastOrEmpty(ema204h)
+	data.LongTerm.Ema504h = lo.LastOrEmpty(ema504h)
+	data.LongTerm.Atr34h = lo.LastOrEmpty(atr34h)
+	data.LongTerm.Atr144h = lo.LastOrEmpty(atr144h)
+	data.LongTerm.VolCurr = lo.LastOrEmpty(vol4h)
+	if len(vol4h) > 0 {
+		data.LongTerm.VolAvg = lo.Sum(vol4h) / float64(len(vol4h))
+	}
+
+	data.LongTerm.MACD4h = lo.Subset(macd4h, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+	data.LongTerm.Rsi144h = lo.Subset(rsi144h, -config.App.Market.SeriesLength, uint(config.App.Market.SeriesLength))
+}
+
+// IntervalString 将时间间隔转换为 K 线周期写法 (e.g. 5m, 4h, 1d)
+func IntervalString(d time.Duration) string {
+	if day := 24 * time.Hour; d >= day && d%day == 0 {
+		return fmt.Sprintf("%.0fd", d.Hours()/24)
+	}
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%.0fh", d.Hours())
+	}
+	return fmt.Sprintf("%.0fm", d.Minutes())
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// MarketQuerier 按需查询提示词之外的行情，供模型在分析时通过工具调用获取更多数据。
+// coin 为币种名 (e.g. "BTC")，返回的序列均从旧到新排列。
+type MarketQuerier interface {
+	// Klines 返回 interval 周期的最近 limit 根 K 线，实时行情包含尚未收盘的当前 K 线
+	Klines(ctx context.Context, coin string, interval time.Duration, limit int) ([]Kline, error)
+	// FundingHistory 返回最近 limit 次结算的资金费率
+	FundingHistory(ctx context.Context, coin string, limit int) ([]FundingRate, error)
+	// OrderBook 返回买卖各 depth 档的盘口
+	OrderBook(ctx context.Context, coin string, depth int) (OrderBook, error)
+	// Ticker 返回最新价格、资金费率与持仓量
+	Ticker(ctx context.Context, coin string) (Ticker, error)
+}
+
+// Ticker 是币种的最新行情摘要
+type Ticker struct {
+	Price        float64 // 最新成交价
+	FundingRate  float64 // 当前 (下一次结算的预测) 资金费率
+	OpenInterest float64 // 持仓量 (单位为基础资产)
+}
+
+// FundingRate 是一次资金费率结算
+type FundingRate struct {
+	Time time.Time
+	Rate float64
+}
+
+// PriceLevel 是盘口的一档
+type PriceLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook 是盘口快照，Bids 从高到低、Asks 从低到高
+type OrderBook struct {
+	Bids []PriceLevel
+	Asks []PriceLevel
+}
+
+// Snapshot 用 q 重新计算 coin 的当前价格与指标，与提示词中的数据口径一致 (持仓量除外)。
+// 资金费率与提示词一样取 Ticker 中当前 (下一次结算的预测) 费率，获取失败时为空。
+func Snapshot(ctx context.Context, q MarketQuerier, coin string) (entity.CoinData, error) {
+	var data entity.CoinData
+	short, err := q.Klines(ctx, coin, config.App.Market.KlineInterval, config.App.Market.KlineLimit)
+	if err != nil {
+		return data, err
+	}
+	long, err := q.Klines(ctx, coin, config.App.Market.KlineIntervalLonger, config.App.Market.KlineLimit)
+	if err != nil {
+		return data, err
+	}
+	if len(short) == 0 || len(long) == 0 {
+		return data, fmt.Errorf("no klines for %s", coin)
+	}
+
+	data.Price = short[len(short)-1].Close
+	fillIntraday(&data, lo.Map(short, func(k Kline, _ int) float64 { return k.Close }))
+	fillLongTerm(&data,
+		lo.Map(long, func(k Kline, _ int) float64 { return k.High }),
+		lo.Map(long, func(k Kline, _ int) float64 { return k.Low }),
+		lo.Map(long, func(k Kline, _ int) float64 { return k.Close }),
+		lo.Map(long, func(k Kline, _ int) float64 { return k.Volume }),
+	)
+	if ticker, err := q.Ticker(ctx, coin); err == nil {
+		data.FundRate = strconv.FormatFloat(ticker.FundingRate, 'f', -1, 64)
+	}
+	return data, nil
+}
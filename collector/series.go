@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// 以下是增量计算的指标，每次输入一个新值，公式与 fillIntraday / fillLongTerm 使用的 indicator 包一致。
+// 状态都是值类型: 在已收盘 K 线的状态上临时计算未收盘 K 线的指标时不会修改原状态。
+
+// ema 与 indicator.Ema 一致，以第一个值为初值
+type ema struct {
+	period int
+	value  float64
+	n      int
+}
+
+func (e ema) next(v float64) ema {
+	if e.n == 0 {
+		e.value = v
+	} else {
+		k := 2 / float64(1+e.period)
+		e.value = v*k + e.value*(1-k)
+	}
+	e.n++
+	return e
+}
+
+// rma 与 indicator.Rma 一致: 前 period 个值取算术平均，之后为 Wilder 平滑
+type rma struct {
+	period int
+	value  float64
+	n      int
+}
+
+func (r rma) next(v float64) rma {
+	if r.n < r.period {
+		r.value = (r.value*float64(r.n) + v) / float64(r.n+1)
+	} else {
+		r.value = (r.value*float64(r.period-1) + v) / float64(r.period)
+	}
+	r.n++
+	return r
+}
+
+// rsi 与 indicator.RsiPeriod 一致，第一个值的涨跌幅记为 0
+type rsi struct {
+	gains, losses rma
+	prev          float64
+	started       bool
+}
+
+func newRsi(period int) rsi {
+	return rsi{gains: rma{period: period}, losses: rma{period: period}}
+}
+
+func (r rsi) next(close float64) rsi {
+	diff := lo.Ternary(r.started, close-r.prev, 0)
+	r.gains = r.gains.next(math.Max(diff, 0))
+	r.losses = r.losses.next(math.Max(-diff, 0))
+	r.prev, r.started = close, true
+	return r
+}
+
+func (r rsi) value() float64 {
+	return 100 - 100/(1+r.gains.value/r.losses.value)
+}
+
+// closeIndicators 是在收盘价序列上增量计算的全部指标
+type closeIndicators struct {
+	ema12, ema20, ema26, ema50 ema
+	rsi7, rsi14                rsi
+}
+
+func newCloseIndicators() closeIndicators {
+	return closeIndicators{
+		ema12: ema{period: 12},
+		ema20: ema{period: 20},
+		ema26: ema{period: 26},
+		ema50: ema{period: 50},
+		rsi7:  newRsi(7),
+		rsi14: newRsi(14),
+	}
+}
+
+func (c closeIndicators) next(close float64) closeIndicators {
+	c.ema12, c.ema20, c.ema26, c.ema50 = c.ema12.next(close), c.ema20.next(close), c.ema26.next(close), c.ema50.next(close)
+	c.rsi7, c.rsi14 = c.rsi7.next(close), c.rsi14.next(close)
+	return c
+}
+
+// indicatorPoint 是一根 K 线收盘 (或当前价格) 处的指标值
+type indicatorPoint struct {
+	close, ema20, ema50, macd, rsi7, rsi14 float64
+}
+
+func (c closeIndicators) point(close float64) indicatorPoint {
+	return indicatorPoint{
+		close: close,
+		ema20: c.ema20.value,
+		ema50: c.ema50.value,
+		macd:  c.ema12.value - c.ema26.value,
+		rsi7:  c.rsi7.value(),
+		rsi14: c.rsi14.value(),
+	}
+}
+
+// klineSeries 是一个币种在一个周期上的 K 线缓冲与增量指标。
+// closed 是截至倒数第二根 (已收盘) K 线的指标状态，最后一根 K 线可能尚未收盘，
+// 它的指标在读取时基于 closed 临时计算。由于指标状态从回填时开始一直延续，
+// 与每次按 KlineLimit 根 K 线重新计算的结果相比只有可以忽略的初值差异。
+type klineSeries struct {
+	interval time.Duration
+	limit    int
+	bars     []Kline
+	closed   closeIndicators
+	history  []indicatorPoint // 最近 historyLen 根已收盘 K 线的指标
+	// historyLen 是需要保留的已收盘 K 线指标数
+	historyLen int
+}
+
+// newKlineSeries 用 REST 回填的 K 线 (从旧到新，最后一根可能未收盘) 初始化缓冲与指标状态
+func newKlineSeries(interval time.Duration, limit, historyLen int, klines []Kline) *klineSeries {
+	s := &klineSeries{interval: interval, limit: limit, closed: newCloseIndicators(), historyLen: historyLen}
+	for _, k := range klines {
+		s.update(k)
+	}
+	return s
+}
+
+// update 用推送的 K 线更新缓冲，同一根 K 线的后续推送会覆盖之前的值。
+// 中间缺少 K 线 (例如连接停顿) 时返回 false，需要重新回填。
+func (s *klineSeries) update(k Kline) bool {
+	if len(s.bars) == 0 {
+		s.bars = append(s.bars, k)
+		return true
+	}
+	last := s.bars[len(s.bars)-1]
+	switch {
+	case k.OpenTime.Before(last.OpenTime):
+		return true // 迟到的推送
+	case k.OpenTime.Equal(last.OpenTime):
+		s.bars[len(s.bars)-1] = k
+		return true
+	case !k.OpenTime.Equal(last.OpenTime.Add(s.interval)):
+		return false
+	}
+
+	// 上一根 K 线已经收盘，计入指标状态
+	s.closed = s.closed.next(last.Close)
+	s.history = append(s.history, s.closed.point(last.Close))
+	if len(s.history) > s.historyLen {
+		s.history = s.history[len(s.history)-s.historyLen:]
+	}
+	s.bars = append(s.bars, k)
+	if len(s.bars) > s.limit {
+		s.bars = s.bars[len(s.bars)-s.limit:]
+	}
+	return true
+}
+
+// last 返回最后一根 K 线
+func (s *klineSeries) last() Kline {
+	return s.bars[len(s.bars)-1]
+}
+
+// points 返回最近 n 根 K 线的指标，最后一个为当前 K 线
+func (s *klineSeries) points(n int) []indicatorPoint {
+	current := s.closed.next(s.last().Close).point(s.last().Close)
+	return append(slices.Clone(lo.Subset(s.history, -(n-1), uint(n-1))), current)
+}
+
+// atr 与 indicator.Atr 一致: 最近 period 根 K 线 max(high-low, high-close, close-low) 的简单平均
+func (s *klineSeries) atr(period int) float64 {
+	return lo.MeanBy(lo.Subset(s.bars, -period, uint(period)), func(k Kline) float64 {
+		return math.Max(k.High-k.Low, math.Max(k.High-k.Close, k.Close-k.Low))
+	})
+}
+
+// fillIntradayFromSeries 与 fillIntraday 相同，但读取 s 中增量计算的指标
+func fillIntradayFromSeries(data *entity.CoinData, s *klineSeries, length int) {
+	points := s.points(length)
+	data.Intraday.Prices3m = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.close })
+	data.Intraday.Ema203m = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.ema20 })
+	data.Intraday.MACD3m = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.macd })
+	data.Intraday.Rsi73m = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.rsi7 })
+	data.Intraday.Rsi143m = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.rsi14 })
+
+	current := points[len(points)-1]
+	data.EMA20, data.MACD, data.RSI7 = current.ema20, current.macd, current.rsi7
+}
+
+// fillLongTermFromSeries 与 fillLongTerm 相同，但读取 s 中增量计算的指标
+func fillLongTermFromSeries(data *entity.CoinData, s *klineSeries, length int) {
+	points := s.points(length)
+	current := points[len(points)-1]
+	data.LongTerm.Ema204h = current.ema20
+	data.LongTerm.Ema504h = current.ema50
+	data.LongTerm.Atr34h = s.atr(3)
+	data.LongTerm.Atr144h = s.atr(14)
+	data.LongTerm.VolCurr = s.last().Volume
+	data.LongTerm.VolAvg = lo.MeanBy(s.bars, func(k Kline) float64 { return k.Volume })
+
+	data.LongTerm.MACD4h = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.macd })
+	data.LongTerm.Rsi144h = lo.Map(points, func(p indicatorPoint, _ int) float64 { return p.rsi14 })
+}
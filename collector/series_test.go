@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// randomKlines 生成 n 根从 start 开始、间隔 interval 的随机游走 K 线
+func randomKlines(rng *rand.Rand, start time.Time, interval time.Duration, n int, price float64) []Kline {
+	klines := make([]Kline, n)
+	for i := range klines {
+		open := price
+		price *= 1 + (rng.Float64()-0.5)*0.02
+		klines[i] = Kline{
+			OpenTime:  start.Add(time.Duration(i) * interval),
+			CloseTime: start.Add(time.Duration(i+1)*interval - time.Millisecond),
+			Open:      open,
+			High:      math.Max(open, price) * (1 + rng.Float64()*0.005),
+			Low:       math.Min(open, price) * (1 - rng.Float64()*0.005),
+			Close:     price,
+			Volume:    100 + rng.Float64()*50,
+		}
+	}
+	return klines
+}
+
+// fromREST 用 fillIntraday / fillLongTerm 按 klines 重新计算，即 REST 采集的结果
+func fromREST(klines []Kline) entity.CoinData {
+	var data entity.CoinData
+	fillIntraday(&data, lo.Map(klines, func(k Kline, _ int) float64 { return k.Close }))
+	fillLongTerm(&data,
+		lo.Map(klines, func(k Kline, _ int) float64 { return k.High }),
+		lo.Map(klines, func(k Kline, _ int) float64 { return k.Low }),
+		lo.Map(klines, func(k Kline, _ int) float64 { return k.Close }),
+		lo.Map(klines, func(k Kline, _ int) float64 { return k.Volume }),
+	)
+	return data
+}
+
+// fromSeries 读取 s 中增量计算的指标，即推送模式的结果
+func fromSeries(s *klineSeries, length int) entity.CoinData {
+	var data entity.CoinData
+	fillIntradayFromSeries(&data, s, length)
+	fillLongTermFromSeries(&data, s, length)
+	return data
+}
+
+func assertClose(t *testing.T, name string, want, got []float64, tolerance float64) {
+	t.Helper()
+	if len(want) != len(got) {
+		t.Fatalf("%s: length %d, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if diff := math.Abs(want[i] - got[i]); diff > tolerance*math.Max(1, math.Abs(want[i])) {
+			t.Errorf("%s[%d] = %v, want %v (diff %g)", name, i, got[i], want[i], diff)
+		}
+	}
+}
+
+func assertCoinData(t *testing.T, want, got entity.CoinData, tolerance float64) {
+	t.Helper()
+	assertClose(t, "Prices", want.Intraday.Prices3m, got.Intraday.Prices3m, tolerance)
+	assertClose(t, "Ema20", want.Intraday.Ema203m, got.Intraday.Ema203m, tolerance)
+	assertClose(t, "MACD", want.Intraday.MACD3m, got.Intraday.MACD3m, tolerance)
+	assertClose(t, "Rsi7", want.Intraday.Rsi73m, got.Intraday.Rsi73m, tolerance)
+	assertClose(t, "Rsi14", want.Intraday.Rsi143m, got.Intraday.Rsi143m, tolerance)
+	assertClose(t, "Current", []float64{want.EMA20, want.MACD, want.RSI7}, []float64{got.EMA20, got.MACD, got.RSI7}, tolerance)
+	assertClose(t, "LongTerm",
+		[]float64{want.LongTerm.Ema204h, want.LongTerm.Ema504h, want.LongTerm.Atr34h, want.LongTerm.Atr144h, want.LongTerm.VolCurr, want.LongTerm.VolAvg},
+		[]float64{got.LongTerm.Ema204h, got.LongTerm.Ema504h, got.LongTerm.Atr34h, got.LongTerm.Atr144h, got.LongTerm.VolCurr, got.LongTerm.VolAvg},
+		tolerance)
+	assertClose(t, "MACD4h", want.LongTerm.MACD4h, got.LongTerm.MACD4h, tolerance)
+	assertClose(t, "Rsi144h", want.LongTerm.Rsi144h, got.LongTerm.Rsi144h, tolerance)
+}
+
+func TestSeriesMatchesIndicatorAfterBackfill(t *testing.T) {
+	const limit, length, interval = 200, 10, 5 * time.Minute
+	config.App.Market.SeriesLength = length
+	rng := rand.New(rand.NewSource(1))
+	klines := randomKlines(rng, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), interval, limit, 100)
+
+	s := newKlineSeries(interval, limit, length, klines)
+	assertCoinData(t, fromREST(klines), fromSeries(s, length), 1e-9)
+}
+
+// 推送模式的指标状态从回填时一直延续，与按最近 limit 根 K 线重新计算相比只有初值带来的微小差异
+func TestSeriesTracksIndicatorWhileStreaming(t *testing.T) {
+	const limit, length, interval = 200, 10, 5 * time.Minute
+	config.App.Market.SeriesLength = length
+	rng := rand.New(rand.NewSource(2))
+	klines := randomKlines(rng, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), interval, limit+150, 100)
+
+	s := newKlineSeries(interval, limit, length, klines[:limit])
+	for i, k := range klines[limit:] {
+		// 未收盘的 K 线会多次推送，先推送一个中间值再推送最终值
+		partial := k
+		partial.Close = (k.Open + k.Close) / 2
+		if !s.update(partial) || !s.update(k) {
+			t.Fatalf("update %d reported a gap", i)
+		}
+	}
+	assertCoinData(t, fromREST(klines[len(klines)-limit:]), fromSeries(s, length), 1e-3)
+}
+
+func TestSeriesDetectsGap(t *testing.T) {
+	const interval = 5 * time.Minute
+	rng := rand.New(rand.NewSource(3))
+	klines := randomKlines(rng, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), interval, 60, 100)
+
+	s := newKlineSeries(interval, 50, 10, klines[:50])
+	if !s.update(klines[49]) {
+		t.Fatal("repeated push of the last kline reported a gap")
+	}
+	if s.update(klines[51]) {
+		t.Fatal("missing kline was not reported as a gap")
+	}
+}
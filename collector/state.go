@@ -5,6 +5,7 @@ import (
 
 	"github.com/gtoxlili/echoAlpha/config"
 	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
 )
 
 type StateProvider interface {
@@ -12,11 +13,41 @@ type StateProvider interface {
 	GetStartingCapital() float64
 }
 
-func ResolveCollector(exchange string, coins []string) StateProvider {
-	switch exchange {
-	case "Binance":
-		return newBinanceProvider(config.BINANCE_API_KEY, config.BINANCE_API_SECRET, coins)
+// ResolveCollector 按交易所名称返回对应的 StateProvider，未知的交易所返回 mock 数据
+func ResolveCollector(name string, coins []string, creds exchange.Credentials) StateProvider {
+	switch name {
+	case exchange.Binance.Name:
+		return withStreaming(newBinanceProvider(creds, coins))
+	case exchange.Bybit.Name:
+		return newBybitProvider(creds, coins)
 	default:
 		return &mockProvider{}
 	}
 }
+
+// ResolveMarketCollector 返回只采集行情的 StateProvider，账户与持仓字段留空
+// 用于模拟盘: 无需交易所 API Key (creds 中只有 BaseURL 会被使用)
+func ResolveMarketCollector(name string, coins []string, creds exchange.Credentials) StateProvider {
+	switch name {
+	case exchange.Binance.Name:
+		return withStreaming(newBinanceMarketProvider(creds, coins))
+	case exchange.Bybit.Name:
+		return newBybitMarketProvider(creds, coins)
+	default:
+		return &mockProvider{}
+	}
+}
+
+// Streamer 是可以通过推送在后台维护行情的 StateProvider (启用 market.streaming 时的 Binance 采集器)
+type Streamer interface {
+	// Stream 开始推送并在后台维护行情直到 ctx 结束，只有长时间运行的命令需要调用，未调用时所有数据通过 REST 获取
+	Stream(ctx context.Context)
+}
+
+// withStreaming 在启用 market.streaming 时把 provider 的行情采集换成 WebSocket 推送，推送在调用 Stream 后开始
+func withStreaming(provider *binanceProvider) StateProvider {
+	if config.App.Market.Streaming {
+		return newBinanceStream(provider)
+	}
+	return provider
+}
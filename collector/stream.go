@@ -0,0 +1,294 @@
+package collector
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+)
+
+const (
+	// streamStaleAfter 内没有收到推送时认为内存中的数据已经过期，回退到 REST
+	streamStaleAfter = time.Minute
+	// maxStreamBackoff 是重连的最长等待时间
+	maxStreamBackoff = time.Minute
+)
+
+// binanceStream 通过 Binance 合约的 WebSocket 推送在内存中维护每个币种的 K 线、增量指标与资金费率，
+// AssemblePromptData 直接读取内存，而不是每个周期为每个币种发起约七次 REST 请求。
+//
+// 持仓量没有推送，按 market.oi_period 在后台轮询；账户与持仓仍然通过 REST 获取。
+// 连接断开后自动重连并用 REST 回填 K 线，K 线不连续时单独回填；尚未就绪或已过期的币种回退到 REST 采集。
+// 推送在调用 Stream 后才开始，之前 (例如只执行一次的子命令) 所有数据都通过 REST 获取。
+type binanceStream struct {
+	*binanceProvider
+
+	mu     sync.RWMutex
+	ctx    context.Context        // Stream 的 ctx，为 nil 时尚未开始推送
+	states map[string]*coinStream // key: symbol (e.g. "BTCUSDT")
+}
+
+// coinStream 是一个币种在内存中的行情
+type coinStream struct {
+	series      map[time.Duration]*klineSeries // 为 nil 时尚未回填
+	backfilling map[time.Duration]bool
+	updatedAt   time.Time // 最近一次收到 K 线推送的时间
+	fundRate    string
+	fundRateAt  time.Time
+	oi          entity.OIFunding // 后台轮询的持仓量，资金费率在推送过期时使用
+	oiAt        time.Time
+}
+
+func newBinanceStream(provider *binanceProvider) *binanceStream {
+	s := &binanceStream{
+		binanceProvider: provider,
+		states:          make(map[string]*coinStream, len(provider.coins)),
+	}
+	for _, symbol := range provider.coins {
+		s.states[symbol] = &coinStream{
+			series:      make(map[time.Duration]*klineSeries, 2),
+			backfilling: make(map[time.Duration]bool, 2),
+		}
+	}
+
+	return s
+}
+
+// Stream 建立推送连接并在后台维护内存中的行情，直到 ctx 结束 (之后数据过期，回退到 REST)。重复调用时不做任何事。
+func (s *binanceStream) Stream(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return
+	}
+	s.ctx = ctx
+
+	intervals := []string{IntervalString(config.App.Market.KlineInterval), IntervalString(config.App.Market.KlineIntervalLonger)}
+	go s.serve(ctx, "K线", func(errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+		return futures.WsCombinedKlineServeMultiInterval(
+			lo.SliceToMap(s.coins, func(symbol string) (string, []string) { return symbol, intervals }),
+			s.onKline, errHandler)
+	}, s.backfillAll)
+	go s.serve(ctx, "资金费率", func(errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+		return futures.WsCombinedMarkPriceServe(s.coins, s.onMarkPrice, errHandler)
+	}, nil)
+	go s.pollOpenInterest(ctx)
+}
+
+// serve 保持一条 WebSocket 连接，断开后按指数退避重连，每次连接建立后调用 onConnect (可以为 nil)
+func (s *binanceStream) serve(
+	ctx context.Context,
+	name string,
+	connect func(errHandler futures.ErrHandler) (doneC, stopC chan struct{}, err error),
+	onConnect func(),
+) {
+	backoff := time.Second
+	for {
+		doneC, stopC, err := connect(func(err error) {
+			log.Printf("⚠️ [行情推送] %s 连接错误: %v", name, err)
+		})
+		if err == nil {
+			log.Printf("... 📡 [行情推送] %s 已连接", name)
+			backoff = time.Second
+			if onConnect != nil {
+				onConnect()
+			}
+			select {
+			case <-ctx.Done():
+				close(stopC)
+				return
+			case <-doneC:
+			}
+			log.Printf("⚠️ [行情推送] %s 连接断开，%v 后重连", name, backoff)
+		} else {
+			log.Printf("⚠️ [行情推送] %s 无法连接: %v，%v 后重连", name, err, backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxStreamBackoff)
+	}
+}
+
+func (s *binanceStream) onKline(event *futures.WsKlineEvent) {
+	k := event.Kline
+	interval := lo.Ternary(k.Interval == IntervalString(config.App.Market.KlineInterval),
+		config.App.Market.KlineInterval, config.App.Market.KlineIntervalLonger)
+	kline := Kline{
+		OpenTime:  time.UnixMilli(k.StartTime),
+		CloseTime: time.UnixMilli(k.EndTime),
+		Open:      parseFloat(k.Open),
+		High:      parseFloat(k.High),
+		Low:       parseFloat(k.Low),
+		Close:     parseFloat(k.Close),
+		Volume:    parseFloat(k.Volume),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[event.Symbol]
+	if !ok {
+		return
+	}
+	series := state.series[interval]
+	if series == nil {
+		s.backfillLocked(event.Symbol, interval) // 上一次回填失败时在这里重试
+		return
+	}
+	if !series.update(kline) {
+		log.Printf("⚠️ [行情推送] %s %s K 线不连续，重新回填", event.Symbol, k.Interval)
+		state.series[interval] = nil
+		s.backfillLocked(event.Symbol, interval)
+		return
+	}
+	state.updatedAt = time.Now()
+}
+
+func (s *binanceStream) onMarkPrice(event *futures.WsMarkPriceEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.states[event.Symbol]; ok {
+		state.fundRate, state.fundRateAt = event.FundingRate, time.Now()
+	}
+}
+
+// backfillAll 在 (重新) 连接后用 REST 回填所有币种的 K 线，覆盖断线期间可能缺失的推送
+func (s *binanceStream) backfillAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, symbol := range s.coins {
+		s.backfillLocked(symbol, config.App.Market.KlineInterval)
+		s.backfillLocked(symbol, config.App.Market.KlineIntervalLonger)
+	}
+}
+
+// backfillLocked 在后台用 REST 重新拉取 symbol 在 interval 上的 K 线并重建缓冲，调用方需持有 s.mu。
+// 同一条序列同时只会有一次回填，回填完成前旧的缓冲 (如果有) 继续使用。
+func (s *binanceStream) backfillLocked(symbol string, interval time.Duration) {
+	state := s.states[symbol]
+	if state.backfilling[interval] {
+		return
+	}
+	state.backfilling[interval] = true
+
+	ctx := s.ctx
+	go func() {
+		klines, err := utils.RetryWithBackoff(func() ([]Kline, error) {
+			return s.binanceProvider.Klines(ctx, exchange.Binance.Coin(symbol), interval, config.App.Market.KlineLimit)
+		}, 3)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		state.backfilling[interval] = false
+		if err != nil {
+			log.Printf("⚠️ [行情推送] 无法回填 %s %s K 线: %v", symbol, IntervalString(interval), err)
+			return
+		}
+		state.series[interval] = newKlineSeries(interval, config.App.Market.KlineLimit, config.App.Market.SeriesLength, klines)
+		state.updatedAt = time.Now()
+	}()
+}
+
+// pollOpenInterest 按 market.oi_period 轮询持仓量
+func (s *binanceStream) pollOpenInterest(ctx context.Context) {
+	period, _ := time.ParseDuration(config.App.Market.OiPeriod)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		for _, symbol := range s.coins {
+			oi, err := s.fetchOIFundingData(ctx, symbol)
+			if err != nil {
+				log.Printf("⚠️ [行情推送] 无法获取 %s 的持仓量: %v", symbol, err)
+				continue
+			}
+			s.mu.Lock()
+			s.states[symbol].oi, s.states[symbol].oiAt = oi, time.Now()
+			s.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *binanceStream) AssemblePromptData(ctx context.Context) (entity.PromptData, error) {
+	s.mu.RLock()
+	streaming := s.ctx != nil
+	s.mu.RUnlock()
+	if !streaming {
+		return s.binanceProvider.AssemblePromptData(ctx)
+	}
+	return s.assemblePromptData(ctx, func(ctx context.Context, symbol string) (entity.CoinData, error) {
+		if data, ok := s.coinData(symbol); ok {
+			return data, nil
+		}
+		log.Printf("⚠️ [行情推送] %s 的推送数据尚未就绪，回退到 REST", symbol)
+		return s.fetchCoinData(ctx, symbol)
+	})
+}
+
+// coinData 从内存中组装 symbol 的行情，数据尚未就绪或已过期时返回 false
+func (s *binanceStream) coinData(symbol string) (entity.CoinData, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state := s.states[symbol]
+	short, long := state.series[config.App.Market.KlineInterval], state.series[config.App.Market.KlineIntervalLonger]
+	if short == nil || long == nil || time.Since(state.updatedAt) > streamStaleAfter || state.oiAt.IsZero() {
+		return entity.CoinData{}, false
+	}
+
+	var data entity.CoinData
+	data.Price = short.last().Close
+	data.OIFunding = state.oi
+	if time.Since(state.fundRateAt) <= streamStaleAfter {
+		data.FundRate = state.fundRate
+	}
+	fillIntradayFromSeries(&data, short, config.App.Market.SeriesLength)
+	fillLongTermFromSeries(&data, long, config.App.Market.SeriesLength)
+	return data, true
+}
+
+// Klines 在内存中的缓冲足够时直接返回缓冲，否则通过 REST 查询
+func (s *binanceStream) Klines(ctx context.Context, coin string, interval time.Duration, limit int) ([]Kline, error) {
+	s.mu.RLock()
+	if state, ok := s.states[exchange.Binance.Symbol(coin)]; ok && time.Since(state.updatedAt) <= streamStaleAfter {
+		if series := state.series[interval]; series != nil && len(series.bars) >= limit {
+			klines := slices.Clone(series.bars[len(series.bars)-limit:])
+			s.mu.RUnlock()
+			return klines, nil
+		}
+	}
+	s.mu.RUnlock()
+	return s.binanceProvider.Klines(ctx, coin, interval, limit)
+}
+
+// Ticker 从内存中读取最新价格与资金费率，持仓量为最近一次轮询的值；数据过期时通过 REST 查询
+func (s *binanceStream) Ticker(ctx context.Context, coin string) (Ticker, error) {
+	s.mu.RLock()
+	state, ok := s.states[exchange.Binance.Symbol(coin)]
+	if ok {
+		short := state.series[config.App.Market.KlineInterval]
+		if short != nil && time.Since(state.updatedAt) <= streamStaleAfter &&
+			time.Since(state.fundRateAt) <= streamStaleAfter && !state.oiAt.IsZero() {
+			ticker := Ticker{Price: short.last().Close, FundingRate: parseFloat(state.fundRate), OpenInterest: state.oi.OILatest}
+			s.mu.RUnlock()
+			return ticker, nil
+		}
+	}
+	s.mu.RUnlock()
+	return s.binanceProvider.Ticker(ctx, coin)
+}
@@ -0,0 +1,301 @@
+package config
+
+import "time"
+
+// Config 是一个机器人实例的全部配置，从 YAML 文件与环境变量加载 (见 Load)
+type Config struct {
+	Exchange ExchangeConfig `yaml:"exchange"`
+	Model    ModelConfig    `yaml:"model"`
+	Coins    []string       `yaml:"coins"` // 交易的币种 (Asset Universe)
+	Market   MarketConfig   `yaml:"market"`
+	Trading  TradingConfig  `yaml:"trading"`
+	Risk     RiskConfig     `yaml:"risk"`
+	Breaker  BreakerConfig  `yaml:"breaker"`
+	Monitor  MonitorConfig  `yaml:"monitor"`
+	Triggers TriggersConfig `yaml:"triggers"`
+	Paper    PaperConfig    `yaml:"paper"`
+	Shadow   ShadowConfig   `yaml:"shadow"`
+	Arena    ArenaConfig    `yaml:"arena"`
+	Ensemble EnsembleConfig `yaml:"ensemble"`
+	Reviewer ReviewerConfig `yaml:"reviewer"`
+	Prompts  PromptsConfig  `yaml:"prompts"`
+	Storage  StorageConfig  `yaml:"storage"`
+	// Pricing 是各模型的价格表，key 为模型名
+	Pricing map[string]ModelPrice `yaml:"pricing"`
+	// Providers 是自定义的 OpenAI 兼容供应商，与内置供应商 (doubao / kimi) 同名时覆盖内置配置
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+type ExchangeConfig struct {
+	Name      string `yaml:"name"`     // Binance / Bybit
+	BaseURL   string `yaml:"base_url"` // 为空时使用交易所的默认地址，测试时可指向本地的 mock 服务
+	APIKey    string `yaml:"api_key"`
+	APISecret string `yaml:"api_secret"`
+}
+
+type ModelConfig struct {
+	Name        string  `yaml:"name"`
+	Provider    string  `yaml:"provider"` // 显式指定供应商，为空时按模型名前缀匹配
+	Temperature float64 `yaml:"temperature"`
+	APIKey      string  `yaml:"api_key"`  // 为空时按模型名前缀使用内置的供应商密钥
+	BaseURL     string  `yaml:"base_url"` // 为空时按模型名前缀使用内置的供应商地址
+	// ResponseFormat 为 json_schema (Structured Outputs) 或 json_object，为空时按模型名前缀选择
+	ResponseFormat string `yaml:"response_format"`
+	// MaxRepairAttempts 是回答无法解析或违反约束时要求模型修正的最大次数
+	MaxRepairAttempts int `yaml:"max_repair_attempts"`
+	// Fallbacks 是主模型出错或超时后依次降级使用的模型
+	Fallbacks []string `yaml:"fallbacks"`
+	// FallbackBudget 是降级前每个模型可以使用的时间占剩余决策时间的比例，最后一个模型可以使用全部剩余时间
+	FallbackBudget float64 `yaml:"fallback_budget"`
+	// Cassette 录制或回放 LLM 回答，使回测与测试可以逐字节复现
+	Cassette CassetteConfig `yaml:"cassette"`
+	// Tools 允许模型在分析时调用工具按需查询额外的行情与交易记录
+	Tools ToolsConfig `yaml:"tools"`
+}
+
+// ToolsConfig 是工具调用模式的设置
+type ToolsConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	MaxCalls int  `yaml:"max_calls"` // 每个模型在一个决策周期内最多调用的次数，用尽后要求模型直接给出决策
+}
+
+// CassetteConfig 是 LLM 回答的录制 / 回放设置，回答按模型与完整对话的哈希保存在 Dir 中
+type CassetteConfig struct {
+	Mode string `yaml:"mode"` // record: 请求模型并保存回答；replay: 只读取已保存的回答，未命中时报错；为空时关闭
+	Dir  string `yaml:"dir"`
+}
+
+// ProviderConfig 是一个 OpenAI 兼容的模型供应商 (e.g. OpenAI、DeepSeek、本地的 vLLM / llama.cpp)
+type ProviderConfig struct {
+	Name      string   `yaml:"name"`
+	Prefixes  []string `yaml:"prefixes"` // 模型名以其中之一开头时使用该供应商
+	BaseURL   string   `yaml:"base_url"`
+	APIKey    string   `yaml:"api_key"`
+	APIKeyEnv string   `yaml:"api_key_env"` // 从该环境变量读取 API Key，api_key 非空时忽略；本地服务可都为空
+	// JSONMode 为 json_schema (Structured Outputs)、json_object 或 none (不发送 response_format)，为空时为 json_object
+	JSONMode string `yaml:"json_mode"`
+	// ReasoningEffort 是发送的 reasoning_effort (minimal / low / medium / high)，为空时不发送
+	ReasoningEffort string `yaml:"reasoning_effort"`
+	// MaxTokens 是单次回答的 max_tokens，为 0 时不发送
+	MaxTokens int `yaml:"max_tokens"`
+	// FixedTemperature 表示不支持自定义 temperature (e.g. OpenAI 的推理模型)，此时不发送 model.temperature
+	FixedTemperature bool `yaml:"fixed_temperature"`
+}
+
+// ModelPrice 是模型的单价 (USD / 百万 token)，推理 token 按输出计费
+type ModelPrice struct {
+	Input       float64 `yaml:"input"`
+	CachedInput float64 `yaml:"cached_input"` // 命中缓存的输入，为 0 时按 Input 计费
+	Output      float64 `yaml:"output"`
+}
+
+type MarketConfig struct {
+	KlineInterval       time.Duration `yaml:"kline_interval"`        // 决策周期，同时也是短线 K 线周期
+	KlineIntervalLonger time.Duration `yaml:"kline_interval_longer"` // 长线 K 线周期
+	KlineLimit          int           `yaml:"kline_limit"`           // 每次拉取的 K 线数量，需足够指标预热
+	SeriesLength        int           `yaml:"series_length"`         // 提示词中每个序列的长度
+	OiPeriod            string        `yaml:"oi_period"`
+	OiLimit             int           `yaml:"oi_limit"`
+	MaxHistoricalValues int           `yaml:"max_historical_values"` // 最多存储的历史账户总价值数据点
+	// Streaming 为 true 时通过 WebSocket 推送在内存中维护行情与指标 (仅支持 Binance)，而不是每个周期通过 REST 拉取
+	Streaming bool `yaml:"streaming"`
+}
+
+type TradingConfig struct {
+	DecisionFrequency string  `yaml:"decision_frequency"`
+	MinLeverage       int     `yaml:"min_leverage"`
+	MaxLeverage       int     `yaml:"max_leverage"`
+	MinConfidence     float64 `yaml:"min_confidence"`      // 低于该信心的信号不执行
+	RecentTradesLimit int     `yaml:"recent_trades_limit"` // 提示词中展示的最近平仓交易数
+}
+
+// RiskConfig 是下单前风控规则，比例都以账户总价值为基准
+type RiskConfig struct {
+	MaxLeverage       int     `yaml:"max_leverage"`
+	MaxNotionalPct    float64 `yaml:"max_notional_pct"`     // 单币种名义价值上限
+	MaxExposurePct    float64 `yaml:"max_exposure_pct"`     // 总名义价值上限
+	MaxRiskPct        float64 `yaml:"max_risk_pct"`         // 单笔止损亏损上限
+	MinLiqDistancePct float64 `yaml:"min_liq_distance_pct"` // 强平价与入场价的最小距离
+	MinNotionalUSD    float64 `yaml:"min_notional_usd"`
+}
+
+// BreakerConfig 是账户级熔断的阈值
+type BreakerConfig struct {
+	MaxDailyDrawdownPct float64       `yaml:"max_daily_drawdown_pct"` // 当日 (UTC) 账户价值较日初的回撤
+	MaxDrawdownPct      float64       `yaml:"max_drawdown_pct"`       // 账户价值较峰值的回撤
+	Cooldown            time.Duration `yaml:"cooldown"`               // 熔断后自动恢复的冷却时间，0 表示只能手动恢复
+	FlattenOnTrip       bool          `yaml:"flatten_on_trip"`        // 熔断时是否立即平掉所有持仓
+}
+
+// MonitorConfig 是两次决策之间检查持仓失效条件的后台监控
+type MonitorConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"` // 检查间隔，每次检查会为每个持仓重新拉取 K 线
+}
+
+// TriggersConfig 是在两次定时决策之间提前开始决策周期的事件，阈值为 0 时不检查对应事件
+type TriggersConfig struct {
+	Enabled                 bool          `yaml:"enabled"`
+	Interval                time.Duration `yaml:"interval"`                  // 检查间隔
+	Cooldown                time.Duration `yaml:"cooldown"`                  // 两次决策之间的最短间隔
+	PriceMoveATR            float64       `yaml:"price_move_atr"`            // 价格较上次决策的变动超过多少个长周期 ATR14
+	ExitProximityPct        float64       `yaml:"exit_proximity_pct"`        // 价格与持仓止损 / 止盈的距离小于价格的多少
+	LiquidationProximityPct float64       `yaml:"liquidation_proximity_pct"` // 价格与持仓强平价的距离小于价格的多少
+	FundingSpike            float64       `yaml:"funding_spike"`             // 资金费率较上次决策的绝对变化
+	OISurgePct              float64       `yaml:"oi_surge_pct"`              // 持仓量较上次决策的相对变化
+}
+
+// PaperConfig 是模拟账户的撮合参数 (回测与 Paper Trading 共用)
+type PaperConfig struct {
+	InitialBalance        float64       `yaml:"initial_balance"`
+	SlippageRate          float64       `yaml:"slippage_rate"`
+	TakerFeeRate          float64       `yaml:"taker_fee_rate"`
+	MaintenanceMarginRate float64       `yaml:"maintenance_margin_rate"`
+	FundingInterval       time.Duration `yaml:"funding_interval"`
+	AccountPath           string        `yaml:"account_path"`
+	PersistencePath       string        `yaml:"persistence_path"`
+	JournalPath           string        `yaml:"journal_path"`
+	UsagePath             string        `yaml:"usage_path"`
+	DecisionLogDir        string        `yaml:"decision_log_dir"`
+}
+
+// ShadowConfig 是影子模式的存储位置，虚拟账户的撮合参数与 PaperConfig 相同。
+// 影子模式的持仓元数据、熔断状态与交易日志和实盘完全分开保存。
+type ShadowConfig struct {
+	AccountPath     string `yaml:"account_path"`
+	PersistencePath string `yaml:"persistence_path"`
+	JournalPath     string `yaml:"journal_path"`
+	AuditPath       string `yaml:"audit_path"` // 本应发送的订单 (JSON Lines)
+	UsagePath       string `yaml:"usage_path"`
+	DecisionLogDir  string `yaml:"decision_log_dir"`
+}
+
+// ArenaConfig 是多模型竞技场的参赛模型与存储目录，每个模型使用独立的模拟账户 (撮合参数与 PaperConfig 相同)
+type ArenaConfig struct {
+	Models []string `yaml:"models"`
+	Dir    string   `yaml:"dir"`
+}
+
+// EnsembleConfig 是集成决策的成员与法定票数，Models 非空时主循环用集成决策代替 model.name
+type EnsembleConfig struct {
+	Models []string `yaml:"models"` // 成员模型，同一模型可以出现多次 (多次采样)
+	Quorum int      `yaml:"quorum"` // 同一币种上同一动作至少需要的票数，为 0 时为成员数的过半数
+}
+
+// ReviewerConfig 是下单前的复核模型，Model 为空时不复核
+type ReviewerConfig struct {
+	Model string `yaml:"model"`
+}
+
+// PromptsConfig 指定自定义的提示词模板文件，为空时使用内置模板
+type PromptsConfig struct {
+	SystemTemplate string `yaml:"system_template"`
+	UserTemplate   string `yaml:"user_template"`
+}
+
+type StorageConfig struct {
+	PersistencePath    string `yaml:"persistence_path"`
+	JournalPath        string `yaml:"journal_path"`
+	UsagePath          string `yaml:"usage_path"`           // LLM 用量与费用账本 (JSON Lines)
+	DecisionLogDir     string `yaml:"decision_log_dir"`     // 每个决策周期的完整审计记录，按天轮转
+	MaxClosedPositions int    `yaml:"max_closed_positions"` // 持久化文件中保留的最近平仓记录数
+}
+
+// App 是当前进程使用的配置，启动时由 Load 的结果替换
+var App = Default()
+
+// Default 返回内置的默认配置
+func Default() *Config {
+	return &Config{
+		Exchange: ExchangeConfig{
+			Name: "Binance",
+		},
+		Model: ModelConfig{
+			Name:              "kimi-k2-thinking-turbo",
+			Temperature:       1.0,
+			MaxRepairAttempts: 2,
+			FallbackBudget:    0.5,
+			Cassette: CassetteConfig{
+				Dir: ".echo-alpha-cassette",
+			},
+			Tools: ToolsConfig{
+				MaxCalls: 8,
+			},
+		},
+		Coins: []string{"BTC", "ETH", "AERO", "BNB", "SOL", "XRP"},
+		Market: MarketConfig{
+			KlineInterval:       5 * time.Minute,
+			KlineIntervalLonger: 4 * time.Hour,
+			KlineLimit:          256,
+			SeriesLength:        16,
+			OiPeriod:            "5m",
+			OiLimit:             288,
+			MaxHistoricalValues: 1 << 10,
+		},
+		Trading: TradingConfig{
+			DecisionFrequency: "Every 6-12 minutes (mid-to-low frequency trading)",
+			MinLeverage:       1,
+			MaxLeverage:       20,
+			MinConfidence:     0.3,
+			RecentTradesLimit: 10,
+		},
+		Risk: RiskConfig{
+			MaxLeverage:       20,
+			MaxNotionalPct:    3.0,  // 单币种名义价值不超过账户价值的 300%
+			MaxExposurePct:    6.0,  // 总名义价值不超过账户价值的 600%
+			MaxRiskPct:        0.05, // 单笔止损亏损不超过账户价值的 5%
+			MinLiqDistancePct: 0.04, // 强平价至少距离入场价 4%
+			MinNotionalUSD:    5.0,
+		},
+		Breaker: BreakerConfig{
+			MaxDailyDrawdownPct: 0.08,
+			MaxDrawdownPct:      0.15,
+			Cooldown:            12 * time.Hour,
+			FlattenOnTrip:       false,
+		},
+		Monitor: MonitorConfig{
+			Enabled:  true,
+			Interval: 30 * time.Second,
+		},
+		Triggers: TriggersConfig{
+			Enabled:                 false,
+			Interval:                30 * time.Second,
+			Cooldown:                2 * time.Minute,
+			PriceMoveATR:            1.0,
+			ExitProximityPct:        0.003, // 距离止损 / 止盈不到 0.3%
+			LiquidationProximityPct: 0.02,  // 距离强平价不到 2%
+			FundingSpike:            0.0005,
+			OISurgePct:              0.05,
+		},
+		Paper: PaperConfig{
+			InitialBalance:        10000.0,
+			SlippageRate:          0.0005, // 市价单滑点 0.05%
+			TakerFeeRate:          0.0005, // 吃单手续费 0.05%
+			MaintenanceMarginRate: 0.004,
+			FundingInterval:       8 * time.Hour,
+			AccountPath:           ".echo-alpha-paper-account.json",
+			PersistencePath:       ".echo-alpha-paper-persistence.json",
+			JournalPath:           ".echo-alpha-paper-journal.jsonl",
+			UsagePath:             ".echo-alpha-paper-usage.jsonl",
+			DecisionLogDir:        ".echo-alpha-paper-decisions",
+		},
+		Shadow: ShadowConfig{
+			AccountPath:     ".echo-alpha-shadow-account.json",
+			PersistencePath: ".echo-alpha-shadow-persistence.json",
+			JournalPath:     ".echo-alpha-shadow-journal.jsonl",
+			AuditPath:       ".echo-alpha-shadow-orders.jsonl",
+			UsagePath:       ".echo-alpha-shadow-usage.jsonl",
+			DecisionLogDir:  ".echo-alpha-shadow-decisions",
+		},
+		Arena: ArenaConfig{
+			Dir: ".echo-alpha-arena",
+		},
+		Storage: StorageConfig{
+			PersistencePath:    ".echo-alpha-persistence.json",
+			JournalPath:        ".echo-alpha-journal.jsonl",
+			UsagePath:          ".echo-alpha-usage.jsonl",
+			DecisionLogDir:     ".echo-alpha-decisions",
+			MaxClosedPositions: 50,
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 是环境变量覆盖的前缀。
+// 每个配置项对应的环境变量名由 YAML 路径拼接而成，例如:
+//
+//	exchange.api_key   → ECHO_ALPHA_EXCHANGE_API_KEY
+//	risk.max_leverage  → ECHO_ALPHA_RISK_MAX_LEVERAGE
+//	coins              → ECHO_ALPHA_COINS (逗号分隔)
+const EnvPrefix = "ECHO_ALPHA_"
+
+// Load 依次应用默认值、path 指向的 YAML 文件 (为空时跳过) 与环境变量，并校验最终结果
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(raw))
+		decoder.KnownFields(true) // 拼错的配置项直接报错，而不是被静默忽略
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(EnvPrefix, "_")); err != nil {
+		return nil, err
+	}
+
+	// 兼容旧版本: 未配置 Binance 密钥时使用编译进来的密钥
+	if cfg.Exchange.Name == "Binance" && cfg.Exchange.APIKey == "" && cfg.Exchange.APISecret == "" {
+		cfg.Exchange.APIKey, cfg.Exchange.APISecret = BINANCE_API_KEY, BINANCE_API_SECRET
+	}
+	cfg.Coins = normalizeCoins(cfg.Coins)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyEnv 递归地用环境变量覆盖 v 中带 yaml 标签的字段
+func applyEnv(v reflect.Value, name string) error {
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			tag := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			if err := applyEnv(v.Field(i), name+"_"+strings.ToUpper(tag)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	raw, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	if err := setValue(v, strings.TrimSpace(raw)); err != nil {
+		return fmt.Errorf("invalid environment variable %s=%q: %w", name, raw, err)
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		parts := strings.Split(raw, ",")
+		v.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// normalizeCoins 统一为大写并去除空白与重复项
+func normalizeCoins(coins []string) []string {
+	seen := make(map[string]bool, len(coins))
+	result := make([]string, 0, len(coins))
+	for _, coin := range coins {
+		coin = strings.ToUpper(strings.TrimSpace(coin))
+		if coin == "" || seen[coin] {
+			continue
+		}
+		seen[coin] = true
+		result = append(result, coin)
+	}
+	return result
+}
+
+// klineIntervals 是各交易所支持的 K 线周期
+var klineIntervals = map[string][]time.Duration{
+	"Binance": {time.Minute, 3 * time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+		time.Hour, 2 * time.Hour, 4 * time.Hour, 6 * time.Hour, 8 * time.Hour, 12 * time.Hour, 24 * time.Hour, 72 * time.Hour, 168 * time.Hour},
+	"Bybit": {time.Minute, 3 * time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+		time.Hour, 2 * time.Hour, 4 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour},
+}
+
+// Validate 检查配置是否合法，返回所有问题的合集
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	between := func(name string, value, lo, hi float64) {
+		check(value >= lo && value <= hi, "%s must be within [%g, %g], got %g", name, lo, hi, value)
+	}
+
+	check(c.Exchange.Name == "Binance" || c.Exchange.Name == "Bybit", "exchange.name must be Binance or Bybit, got %q", c.Exchange.Name)
+	check(c.Model.Name != "", "model.name is required")
+	between("model.temperature", c.Model.Temperature, 0, 2)
+	check(lo.Contains([]string{"", "json_schema", "json_object", "none"}, c.Model.ResponseFormat), "model.response_format must be json_schema, json_object or none, got %q", c.Model.ResponseFormat)
+	check(c.Model.MaxRepairAttempts >= 0, "model.max_repair_attempts must not be negative")
+	check(!lo.Contains(c.Model.Fallbacks, c.Model.Name) && len(lo.FindDuplicates(c.Model.Fallbacks)) == 0, "model.fallbacks must not contain model.name or duplicate models")
+	check(c.Model.FallbackBudget > 0 && c.Model.FallbackBudget <= 1, "model.fallback_budget must be within (0, 1], got %g", c.Model.FallbackBudget)
+	check(lo.Contains([]string{"", "record", "replay"}, c.Model.Cassette.Mode), "model.cassette.mode must be record or replay, got %q", c.Model.Cassette.Mode)
+	check(c.Model.Cassette.Mode == "" || c.Model.Cassette.Dir != "", "model.cassette.dir is required when model.cassette.mode is set")
+	check(!c.Model.Tools.Enabled || c.Model.Tools.MaxCalls > 0, "model.tools.max_calls must be positive when model.tools.enabled is set")
+	check(len(c.Coins) > 0, "coins must not be empty")
+	check(len(c.Ensemble.Models) != 1, "ensemble.models needs at least two members")
+	check(c.Ensemble.Quorum >= 0 && c.Ensemble.Quorum <= len(c.Ensemble.Models), "ensemble.quorum must be within [0, %d], got %d", len(c.Ensemble.Models), c.Ensemble.Quorum)
+	for i, p := range c.Providers {
+		check(p.Name != "", "providers[%d].name is required", i)
+		check(p.BaseURL != "", "providers[%d].base_url is required", i)
+		check(lo.Contains([]string{"", "json_schema", "json_object", "none"}, p.JSONMode), "providers[%d].json_mode must be json_schema, json_object or none, got %q", i, p.JSONMode)
+		check(lo.Contains([]string{"", "minimal", "low", "medium", "high"}, p.ReasoningEffort), "providers[%d].reasoning_effort must be minimal, low, medium or high, got %q", i, p.ReasoningEffort)
+		check(p.MaxTokens >= 0, "providers[%d].max_tokens must not be negative", i)
+	}
+	check(len(lo.FindDuplicatesBy(c.Providers, func(p ProviderConfig) string { return p.Name })) == 0, "providers must not contain duplicate names")
+
+	m := c.Market
+	// 决策超时为 kline_interval - 1m，至少需要留出一些时间给 LLM
+	check(m.KlineInterval > time.Minute, "market.kline_interval must be longer than 1m, got %s", m.KlineInterval)
+	if intervals, ok := klineIntervals[c.Exchange.Name]; ok {
+		supported := strings.Join(lo.Map(intervals, func(d time.Duration, _ int) string { return d.String() }), ", ")
+		check(lo.Contains(intervals, m.KlineInterval), "market.kline_interval %s is not supported by %s (%s)", m.KlineInterval, c.Exchange.Name, supported)
+		check(lo.Contains(intervals, m.KlineIntervalLonger), "market.kline_interval_longer %s is not supported by %s (%s)", m.KlineIntervalLonger, c.Exchange.Name, supported)
+	}
+	check(m.KlineIntervalLonger > m.KlineInterval, "market.kline_interval_longer (%s) must be longer than market.kline_interval (%s)", m.KlineIntervalLonger, m.KlineInterval)
+	check(m.SeriesLength > 0, "market.series_length must be positive")
+	check(m.KlineLimit >= max(m.SeriesLength, 50), "market.kline_limit must be at least max(series_length, 50) for indicator warmup, got %d", m.KlineLimit)
+	_, err := time.ParseDuration(m.OiPeriod)
+	check(err == nil, "market.oi_period must be a duration like 5m, got %q", m.OiPeriod)
+	check(m.OiLimit > 0, "market.oi_limit must be positive")
+	check(m.MaxHistoricalValues > 0, "market.max_historical_values must be positive")
+	check(!m.Streaming || c.Exchange.Name == "Binance", "market.streaming is only supported on Binance")
+
+	t := c.Trading
+	check(t.MinLeverage >= 1 && t.MinLeverage <= t.MaxLeverage, "trading.min_leverage must be within [1, max_leverage], got %d", t.MinLeverage)
+	between("trading.min_confidence", t.MinConfidence, 0, 1)
+	check(t.RecentTradesLimit >= 0, "trading.recent_trades_limit must not be negative")
+
+	r := c.Risk
+	check(r.MaxLeverage >= 1, "risk.max_leverage must be at least 1, got %d", r.MaxLeverage)
+	check(r.MaxNotionalPct >= 0, "risk.max_notional_pct must not be negative")
+	check(r.MaxExposurePct >= 0, "risk.max_exposure_pct must not be negative")
+	between("risk.max_risk_pct", r.MaxRiskPct, 0, 1)
+	between("risk.min_liq_distance_pct", r.MinLiqDistancePct, 0, 1)
+	check(r.MinNotionalUSD >= 0, "risk.min_notional_usd must not be negative")
+
+	between("breaker.max_daily_drawdown_pct", c.Breaker.MaxDailyDrawdownPct, 0, 1)
+	between("breaker.max_drawdown_pct", c.Breaker.MaxDrawdownPct, 0, 1)
+	check(c.Breaker.Cooldown >= 0, "breaker.cooldown must not be negative")
+	check(!c.Monitor.Enabled || c.Monitor.Interval > 0, "monitor.interval must be positive when monitor.enabled is set")
+
+	tr := c.Triggers
+	check(!tr.Enabled || tr.Interval > 0, "triggers.interval must be positive when triggers.enabled is set")
+	check(tr.Cooldown >= 0, "triggers.cooldown must not be negative")
+	check(tr.PriceMoveATR >= 0 && tr.ExitProximityPct >= 0 && tr.LiquidationProximityPct >= 0 && tr.FundingSpike >= 0 && tr.OISurgePct >= 0,
+		"triggers thresholds must not be negative")
+
+	p := c.Paper
+	check(p.InitialBalance > 0, "paper.initial_balance must be positive")
+	between("paper.slippage_rate", p.SlippageRate, 0, 1)
+	between("paper.taker_fee_rate", p.TakerFeeRate, 0, 1)
+	between("paper.maintenance_margin_rate", p.MaintenanceMarginRate, 0, 1)
+	check(p.FundingInterval > 0, "paper.funding_interval must be positive")
+
+	check(len(lo.Uniq(c.Arena.Models)) == len(c.Arena.Models), "arena.models must not contain duplicates")
+
+	for name, path := range map[string]string{
+		"prompts.system_template": c.Prompts.SystemTemplate,
+		"prompts.user_template":   c.Prompts.UserTemplate,
+	} {
+		if path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, "%s: %v", name, err)
+		}
+	}
+	for model, price := range c.Pricing {
+		check(price.Input >= 0 && price.CachedInput >= 0 && price.Output >= 0, "pricing.%s must not be negative", model)
+	}
+	check(c.Storage.MaxClosedPositions >= 0, "storage.max_closed_positions must not be negative")
+
+	return errors.Join(errs...)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"maps"
 	"os"
 	"sync"
 
@@ -11,24 +12,45 @@ import (
 type Persistence struct {
 	PortfolioAnalysis string                          `json:"portfolio_analysis"`
 	OpenPositions     map[string]entity.TradeMetadata `json:"open_positions"`
+	Breaker           entity.BreakerState             `json:"breaker"`
+	ClosedPositions   []entity.ClosedPosition         `json:"closed_positions"` // 最近平仓的持仓，最多保留 MaxClosedPositions 条
+
+	mu       sync.Mutex
+	path     string // 为空时只保存在内存中 (回测等场景不应污染实盘状态)
+	readOnly bool   // 为 true 时从 path 加载，但修改只保存在内存中
 }
 
-var (
-	AppPersistence *Persistence
-	mu             sync.Mutex
-)
+// NewPersistence 从 path 加载持久化状态，文件不存在或损坏时使用默认值
+// path 为空时返回一个纯内存的实例
+func NewPersistence(path string) *Persistence {
+	p := defaultPersistence()
+	p.path = path
+	if path == "" {
+		return p
+	}
 
-func init() {
 	// 从配置文件中获取
-	file, err := os.Open(PersistencePath)
+	file, err := os.Open(path)
 	if err != nil {
-		AppPersistence = defaultPersistence()
-		return
+		return p
 	}
 	defer file.Close()
-	if err := json.NewDecoder(file).Decode(&AppPersistence); err != nil {
-		AppPersistence = defaultPersistence()
+	if err := json.NewDecoder(file).Decode(p); err != nil {
+		p = defaultPersistence()
+		p.path = path
+	}
+	if p.OpenPositions == nil {
+		p.OpenPositions = make(map[string]entity.TradeMetadata)
 	}
+	return p
+}
+
+// NewSnapshot 与 NewPersistence 一样从 path 加载状态，但之后的修改只保存在内存中，
+// 用于 dry-run 等不应改写实盘状态的场景
+func NewSnapshot(path string) *Persistence {
+	p := NewPersistence(path)
+	p.readOnly = true
+	return p
 }
 
 func defaultPersistence() *Persistence {
@@ -38,26 +60,81 @@ func defaultPersistence() *Persistence {
 	}
 }
 
-func SavePortfolioPersistence(portfolioAnalysis string) error {
-	mu.Lock()
-	defer mu.Unlock()
-	AppPersistence.PortfolioAnalysis = portfolioAnalysis
-	file, err := os.OpenFile(PersistencePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
+func (p *Persistence) SavePortfolioAnalysis(portfolioAnalysis string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.PortfolioAnalysis = portfolioAnalysis
+	return p.flush()
+}
+
+// SaveOpenPositions 保存 openPositions 的副本，调用方之后对 openPositions 的修改不会影响这里，
+// flush 编码时不会与调用方的写入并发访问同一个 map
+func (p *Persistence) SaveOpenPositions(openPositions map[string]entity.TradeMetadata) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.OpenPositions = maps.Clone(openPositions)
+	return p.flush()
+}
+
+// SaveClosedPosition 在移除持仓元数据的同时记录平仓信息，与 SaveOpenPositions 一样保存 openPositions 的副本
+func (p *Persistence) SaveClosedPosition(openPositions map[string]entity.TradeMetadata, closed entity.ClosedPosition) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.OpenPositions = maps.Clone(openPositions)
+	p.ClosedPositions = append(p.ClosedPositions, closed)
+	if over := len(p.ClosedPositions) - App.Storage.MaxClosedPositions; over > 0 {
+		p.ClosedPositions = p.ClosedPositions[over:]
 	}
-	defer file.Close()
-	return json.NewEncoder(file).Encode(AppPersistence)
+	return p.flush()
 }
 
-func SaveOpenPositions(openPositions map[string]entity.TradeMetadata) error {
-	mu.Lock()
-	defer mu.Unlock()
-	AppPersistence.OpenPositions = openPositions
-	file, err := os.OpenFile(PersistencePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+func (p *Persistence) SaveBreakerState(state entity.BreakerState) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Breaker = state
+	return p.flush()
+}
+
+// breakerResetSuffix 是熔断复位请求标记文件相对状态文件的后缀
+const breakerResetSuffix = ".reset-breaker"
+
+// RequestBreakerReset 请求复位熔断器。
+// 状态文件由持有它的交易进程整体写回，其他进程直接修改熔断状态会被覆盖，
+// 因此只在状态文件旁留下一个标记文件，由交易进程在下一次观测账户价值时处理。
+func (p *Persistence) RequestBreakerReset() error {
+	if p.path == "" {
+		return nil
+	}
+	return os.WriteFile(p.path+breakerResetSuffix, nil, 0644)
+}
+
+// BreakerResetPending 返回是否有尚未处理的熔断复位请求
+func (p *Persistence) BreakerResetPending() bool {
+	if p.path == "" {
+		return false
+	}
+	_, err := os.Stat(p.path + breakerResetSuffix)
+	return err == nil
+}
+
+// TakeBreakerReset 取走尚未处理的熔断复位请求，有请求时返回 true。
+// 只在内存中的实例 (dry-run 等) 不处理请求，留给真正持有状态的进程。
+func (p *Persistence) TakeBreakerReset() bool {
+	if p.path == "" || p.readOnly {
+		return false
+	}
+	return os.Remove(p.path+breakerResetSuffix) == nil
+}
+
+// flush 将当前状态完整写回文件，调用方需持有 p.mu
+func (p *Persistence) flush() error {
+	if p.path == "" || p.readOnly {
+		return nil
+	}
+	file, err := os.OpenFile(p.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	return json.NewEncoder(file).Encode(AppPersistence)
+	return json.NewEncoder(file).Encode(p)
 }
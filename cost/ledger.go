@@ -0,0 +1,124 @@
+package cost
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// Record 是一个模型在一个决策周期中的 LLM 用量与费用，降级或集成决策时同一周期有多条记录
+type Record struct {
+	Time    time.Time `json:"time"`
+	CycleID string    `json:"cycle_id,omitempty"`
+	entity.Usage
+	CostUSD  float64 `json:"cost_usd"`
+	Priced   bool    `json:"priced"`          // 模型在价格表中，CostUSD 有效
+	Executed int     `json:"executed"`        // 本周期成功执行的开仓 / 平仓订单数，同一周期只记在最后一条记录上
+	Error    string  `json:"error,omitempty"` // AI 分析失败的原因，失败的请求同样计费
+}
+
+// Ledger 是 LLM 用量与费用的账本，以 JSON Lines 的形式追加写入 path
+type Ledger struct {
+	path string // 为空时只保存在内存中
+
+	mu      sync.Mutex
+	records []Record
+	warned  map[string]bool
+}
+
+// New 从 path 加载已有的记录，path 为空时返回一个纯内存的实例
+func New(path string) *Ledger {
+	l := &Ledger{path: path, warned: make(map[string]bool)}
+	if path == "" {
+		return l
+	}
+	records, err := load(path)
+	if err != nil {
+		log.Printf("⚠️ [费用] 无法读取 %s: %v", path, err)
+	}
+	l.records = records
+	return l
+}
+
+// Price 按 config.App.Pricing 计算 usage 的费用 (USD)，模型不在价格表中时返回 false
+func Price(usage entity.Usage) (float64, bool) {
+	price, ok := config.App.Pricing[usage.Model]
+	if !ok {
+		return 0, false
+	}
+	cachedRate := lo.Ternary(price.CachedInput > 0, price.CachedInput, price.Input)
+	cost := float64(usage.PromptTokens-usage.CachedTokens)*price.Input +
+		float64(usage.CachedTokens)*cachedRate +
+		float64(usage.CompletionTokens)*price.Output
+	return cost / 1e6, true
+}
+
+// Add 为 r 计算费用后写入账本
+func (l *Ledger) Add(r Record) Record {
+	r.CostUSD, r.Priced = Price(r.Usage)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !r.Priced && !l.warned[r.Model] {
+		l.warned[r.Model] = true
+		log.Printf("⚠️ [费用] 价格表 (pricing) 中没有 %s，只记录 token 用量。", r.Model)
+	}
+	l.records = append(l.records, r)
+	if err := l.append(r); err != nil {
+		log.Printf("⚠️ [费用] 无法写入 %s: %v", l.path, err)
+	}
+	log.Printf("💸 [费用] %s: 输入 %d (缓存 %d), 输出 %d (推理 %d) tokens, %d 次请求, 耗时 %v, 费用 $%.4f",
+		r.Model, r.PromptTokens, r.CachedTokens, r.CompletionTokens, r.ReasoningTokens, r.Requests, r.Latency.Round(time.Millisecond), r.CostUSD)
+	return r
+}
+
+// Query 返回时间在 [from, to] 内的记录，from / to 为零值时不限制对应的边界
+func (l *Ledger) Query(from, to time.Time) []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return lo.Filter(l.records, func(r Record, _ int) bool {
+		return (from.IsZero() || !r.Time.Before(from)) && (to.IsZero() || !r.Time.After(to))
+	})
+}
+
+// append 把一条记录追加到账本文件，调用方需持有 l.mu
+func (l *Ledger) append(r Record) error {
+	if l.path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(r)
+}
+
+func load(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // 跳过写入中断产生的残行
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
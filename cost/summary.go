@@ -0,0 +1,115 @@
+package cost
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// Summary 汇总一组记录的用量与费用，可选地与同期交易的净盈亏对比
+type Summary struct {
+	Cycles           int
+	Executed         int
+	PromptTokens     int64
+	CompletionTokens int64
+	ReasoningTokens  int64
+	CostUSD          float64
+	Unpriced         int     // 模型不在价格表中的记录数，其费用未计入 CostUSD
+	NetPnL           float64 // 同期平仓交易的净盈亏
+
+	seen map[string]bool // 已计入 Cycles 的周期 ID，同一周期的多条记录只计一次
+}
+
+func (s *Summary) add(r Record) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if r.CycleID == "" || !s.seen[r.CycleID] {
+		s.seen[r.CycleID] = true
+		s.Cycles++
+	}
+	s.Executed += r.Executed
+	s.PromptTokens += r.PromptTokens
+	s.CompletionTokens += r.CompletionTokens
+	s.ReasoningTokens += r.ReasoningTokens
+	s.CostUSD += r.CostUSD
+	if !r.Priced {
+		s.Unpriced++
+	}
+}
+
+func (s Summary) CostPerCycle() float64 {
+	if s.Cycles == 0 {
+		return 0
+	}
+	return s.CostUSD / float64(s.Cycles)
+}
+
+func (s Summary) CostPerTrade() float64 {
+	if s.Executed == 0 {
+		return 0
+	}
+	return s.CostUSD / float64(s.Executed)
+}
+
+// Report 是总计以及按模型、按日 (UTC) 的分组汇总
+type Report struct {
+	Total   Summary
+	ByModel map[string]Summary
+	ByDay   map[string]Summary // key: 2006-01-02
+}
+
+// Summarize 汇总 records，trades 的净盈亏按平仓日计入 ByDay 与 Total
+func Summarize(records []Record, trades []entity.ClosedTrade) Report {
+	report := Report{ByModel: make(map[string]Summary), ByDay: make(map[string]Summary)}
+	for _, r := range records {
+		report.Total.add(r)
+		addTo(report.ByModel, r.Model, r)
+		addTo(report.ByDay, r.Time.UTC().Format(time.DateOnly), r)
+	}
+	for _, t := range trades {
+		day := t.ExitTime.UTC().Format(time.DateOnly)
+		s := report.ByDay[day]
+		s.NetPnL += t.NetPnL
+		report.ByDay[day] = s
+		report.Total.NetPnL += t.NetPnL
+	}
+	return report
+}
+
+func addTo(m map[string]Summary, key string, r Record) {
+	s := m[key]
+	s.add(r)
+	m[key] = s
+}
+
+func (r Report) Log() {
+	log.Println("💸 [费用] ===== 按日 (UTC) =====")
+	days := lo.Keys(r.ByDay)
+	sort.Strings(days)
+	for _, day := range days {
+		s := r.ByDay[day]
+		log.Printf("   ... %s 周期 %4d, 成交 %3d, 费用 $%8.4f (每周期 $%.4f, 每笔 $%.4f), 净盈亏 $%9.2f",
+			day, s.Cycles, s.Executed, s.CostUSD, s.CostPerCycle(), s.CostPerTrade(), s.NetPnL)
+	}
+
+	log.Println("💸 [费用] ===== 按模型 =====")
+	models := lo.Keys(r.ByModel)
+	sort.Strings(models)
+	for _, model := range models {
+		s := r.ByModel[model]
+		log.Printf("   ... %-32s 周期 %4d, 输入 %d, 输出 %d (推理 %d) tokens, 费用 $%.4f (每周期 $%.4f, 每笔 $%.4f)",
+			model, s.Cycles, s.PromptTokens, s.CompletionTokens, s.ReasoningTokens, s.CostUSD, s.CostPerCycle(), s.CostPerTrade())
+	}
+
+	t := r.Total
+	log.Println("💸 [费用] ===== 汇总 =====")
+	log.Printf("   ... 周期 %d, 成交 %d, 费用 $%.4f (每周期 $%.4f, 每笔 $%.4f)", t.Cycles, t.Executed, t.CostUSD, t.CostPerCycle(), t.CostPerTrade())
+	log.Printf("   ... 同期净盈亏 $%.2f, 扣除 LLM 费用后 $%.2f", t.NetPnL, t.NetPnL-t.CostUSD)
+	if t.Unpriced > 0 {
+		log.Printf("   ... ⚠️ %d 条记录的模型不在价格表中，费用未计入", t.Unpriced)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/cost"
+	"github.com/gtoxlili/echoAlpha/journal"
+)
+
+// runCost 按 -from、-to 汇总 LLM 费用账本，并与同期交易日志的净盈亏对比 (与 -paper / -shadow 组合时读取对应模式的数据)
+func runCost(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	rawFrom := fs.String("from", "", "时间下界 (UTC, 2006-01-02 或 2006-01-02 15:04:05)")
+	rawTo := fs.String("to", "", "时间上界 (UTC, 格式同 -from，包含在内，只有日期时包含当天全天)")
+	fs.Parse(args)
+
+	from, err := parseBacktestTime(*rawFrom)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to, err := parseEndTime(*rawTo)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	paths := statePaths(config.App)
+	records := cost.New(paths.Usage).Query(from, to)
+	trades := journal.New(paths.Journal, nil).Query("", from, to)
+	cost.Summarize(records, trades).Log()
+	return nil
+}
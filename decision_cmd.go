@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/decisionlog"
+)
+
+// runDecision 以 JSON 输出一个周期的决策审计记录，默认读取当前模式的审计日志目录
+func runDecision(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("decision", flag.ExitOnError)
+	dir := fs.String("dir", "", "审计日志目录 (e.g. 回测输出目录下的 decisions)，默认为当前模式的 decision_log_dir")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("用法: decision [-dir <dir>] <cycle-id>")
+	}
+	if *dir == "" {
+		*dir = statePaths(config.App).DecisionLog
+	}
+
+	record, err := decisionlog.New(*dir).Find(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("无法读取周期 %s: %w", fs.Arg(0), err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(record)
+}
@@ -0,0 +1,171 @@
+package decisionlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+)
+
+// ErrNotFound 表示审计日志中没有指定的周期
+var ErrNotFound = errors.New("cycle not found")
+
+// Execution 是一个 action 提交执行后的结果
+type Execution struct {
+	Signal string `json:"signal"`
+	Coin   string `json:"coin"`
+	DryRun bool   `json:"dry_run,omitempty"` // -dry-run 时订单没有发送
+	Error  string `json:"error,omitempty"`   // 为空表示执行成功
+}
+
+// Review 是复核模型对本周期决策的复核
+type Review struct {
+	Model    string          `json:"model"`
+	Trace    entity.Trace    `json:"trace"`
+	Verdicts []entity.Review `json:"verdicts"`
+	Error    string          `json:"error,omitempty"` // 复核失败的原因，此时没有 action 被执行
+}
+
+// Record 是一个决策周期的完整审计记录，足以复盘模型看到了什么、回答了什么以及最终下了哪些单
+type Record struct {
+	CycleID    string                `json:"cycle_id"`
+	Time       time.Time             `json:"time"`
+	Data       entity.PromptData     `json:"data"`
+	Model      string                `json:"model,omitempty"`    // 给出决策的模型，降级时不是主模型
+	Trace      entity.Trace          `json:"trace"`              // 提示词、每次请求的原始回答与推理内容
+	Decision   *entity.AgentDecision `json:"decision,omitempty"` // 通过校验的决策，AI 分析失败或被跳过时为空
+	Review     *Review               `json:"review,omitempty"`   // 复核模型的裁决，未启用复核或没有需要复核的 action 时为空
+	Actions    []entity.TradeSignal  `json:"actions"`            // 经熔断、信心阈值、复核与风控过滤后提交执行的 action
+	Executions []Execution           `json:"executions"`
+	Error      string                `json:"error,omitempty"` // AI 分析失败的原因
+}
+
+// indexEntry 记录一个周期在哪个日文件的哪个偏移处
+type indexEntry struct {
+	CycleID string `json:"cycle_id"`
+	File    string `json:"file"`
+	Offset  int64  `json:"offset"`
+}
+
+// Log 是按天 (UTC) 轮转的决策审计日志。
+// 记录以 JSON Lines 的形式追加写入 <dir>/2006-01-02.jsonl，<dir>/index.jsonl 记录每个周期 ID 所在的文件与偏移。
+type Log struct {
+	dir string // 为空时不写入
+
+	mu sync.Mutex
+}
+
+// New 返回写入 dir 的审计日志，dir 为空时 Write 不做任何事
+func New(dir string) *Log {
+	return &Log{dir: dir}
+}
+
+// NewCycleID 返回 t 对应的周期 ID (UTC, e.g. 20250101-083000)
+func NewCycleID(t time.Time) string {
+	return t.UTC().Format("20060102-150405")
+}
+
+// Write 把 r 追加到 r.Time 当天的日志文件并更新索引
+func (l *Log) Write(r Record) error {
+	if l.dir == "" {
+		return nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal decision record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+	name := r.Time.UTC().Format(time.DateOnly) + ".jsonl"
+	file, err := os.OpenFile(filepath.Join(l.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return l.appendIndex(indexEntry{CycleID: r.CycleID, File: name, Offset: info.Size()})
+}
+
+// Find 按周期 ID 读取审计记录，同一 ID 出现多次时返回最后写入的一条
+func (l *Log) Find(cycleID string) (Record, error) {
+	entry, err := l.lookup(cycleID)
+	if err != nil {
+		return Record{}, err
+	}
+	file, err := os.Open(filepath.Join(l.dir, entry.File))
+	if err != nil {
+		return Record{}, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(entry.Offset, io.SeekStart); err != nil {
+		return Record{}, err
+	}
+	// 一条记录包含完整的提示词与回答，可能超过 bufio.Scanner 的单行上限，因此直接解码
+	var r Record
+	if err := json.NewDecoder(file).Decode(&r); err != nil {
+		return Record{}, fmt.Errorf("failed to decode %s at offset %d: %w", entry.File, entry.Offset, err)
+	}
+	return r, nil
+}
+
+// appendIndex 追加一条索引，调用方需持有 l.mu
+func (l *Log) appendIndex(entry indexEntry) error {
+	file, err := os.OpenFile(filepath.Join(l.dir, "index.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(entry)
+}
+
+func (l *Log) lookup(cycleID string) (indexEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(filepath.Join(l.dir, "index.jsonl"))
+	if os.IsNotExist(err) {
+		return indexEntry{}, ErrNotFound
+	}
+	if err != nil {
+		return indexEntry{}, err
+	}
+	defer file.Close()
+
+	var (
+		found indexEntry
+		ok    bool
+	)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry indexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // 跳过写入中断产生的残行
+		}
+		if entry.CycleID == cycleID {
+			found, ok = entry, true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return indexEntry{}, err
+	}
+	if !ok {
+		return indexEntry{}, ErrNotFound
+	}
+	return found, nil
+}
@@ -16,6 +16,59 @@ type TradeMetadata struct {
 	Confidence            float64   `json:"confidence"`
 	RiskUSD               float64   `json:"risk_usd"`
 	Justification         string    `json:"justification"`
+	Side                  string    `json:"side"` // long / short
+	Leverage              int       `json:"leverage"`
+	Quantity              float64   `json:"quantity"`
+	EntryPrice            float64   `json:"entry_price"`       // 成交均价，开仓后的第一次对账时从交易所持仓中补全
+	Adopted               bool      `json:"adopted,omitempty"` // 由对账接管的外部持仓，EntryTime 为接管时间
+}
+
+// CloseReason 记录持仓被平掉的原因
+type CloseReason string
+
+const (
+	CloseReasonSignal       CloseReason = "close_signal" // AI 发出 close 信号
+	CloseReasonStopLoss     CloseReason = "stop_loss"
+	CloseReasonTakeProfit   CloseReason = "take_profit"
+	CloseReasonLiquidation  CloseReason = "liquidation"
+	CloseReasonManual       CloseReason = "manual"       // 在机器人之外手动平仓
+	CloseReasonBreaker      CloseReason = "breaker"      // 熔断时强制平仓
+	CloseReasonInvalidation CloseReason = "invalidation" // 两次决策之间失效条件成立，由监控平仓
+	CloseReasonUnknown      CloseReason = "unknown"
+)
+
+// ClosedPosition 是一个已平仓持仓的元数据与平仓信息
+type ClosedPosition struct {
+	TradeMetadata
+	ClosedAt  time.Time   `json:"closed_at"`
+	Reason    CloseReason `json:"reason"`
+	ExitPrice float64     `json:"exit_price,omitempty"` // 无法从交易所查询到时为 0
+}
+
+// Settlement 是一笔交易在交易所收益流水中的结算金额，符号与流水一致: 正数为收入，负数为支出
+type Settlement struct {
+	RealizedPnL float64 `json:"realized_pnl"`
+	Commission  float64 `json:"commission"`
+	Funding     float64 `json:"funding"`
+	NetPnL      float64 `json:"net_pnl"` // RealizedPnL + Commission + Funding
+}
+
+// ClosedTrade 是交易日志中的一条记录: 一笔完整的开仓 → 平仓
+type ClosedTrade struct {
+	Coin                  string      `json:"coin"`
+	Side                  string      `json:"side"`
+	Leverage              int         `json:"leverage"`
+	Quantity              float64     `json:"quantity"`
+	EntryTime             time.Time   `json:"entry_time"`
+	ExitTime              time.Time   `json:"exit_time"`
+	EntryPrice            float64     `json:"entry_price"`
+	ExitPrice             float64     `json:"exit_price"`
+	Justification         string      `json:"justification"`
+	InvalidationCondition string      `json:"invalidation_condition"`
+	Confidence            float64     `json:"confidence"`
+	Reason                CloseReason `json:"reason"`
+	Adopted               bool        `json:"adopted,omitempty"`
+	Settlement
 }
 
 type TradeSignal struct {
@@ -34,9 +87,90 @@ type TradeSignal struct {
 type AgentDecision struct {
 	PortfolioAnalysis string        `json:"portfolio_analysis"`
 	Actions           []TradeSignal `json:"actions"`
+	Model             string        `json:"-"` // 给出该决策的模型，降级时不是主模型
 }
 
 func (ar AgentDecision) Print() {
 	display, _ := json.MarshalIndent(ar, "", "  ")
 	fmt.Printf("%s\n", display)
 }
+
+// 复核模型对一个 action 的裁决
+const (
+	VerdictApprove = "approve"
+	VerdictReject  = "reject"
+	VerdictTighten = "tighten" // 收紧数量、杠杆或止盈止损后通过
+)
+
+// Review 是复核模型对一个 action 的裁决，tighten 时非零的数值字段为建议的新值
+type Review struct {
+	Coin         string  `json:"coin"`
+	Verdict      string  `json:"verdict"`
+	Reason       string  `json:"reason"`
+	Quantity     float64 `json:"quantity"`
+	Leverage     int     `json:"leverage"`
+	StopLoss     float64 `json:"stop_loss"`
+	ProfitTarget float64 `json:"profit_target"`
+}
+
+// ReviewResult 是复核模型的回答
+type ReviewResult struct {
+	Reviews []Review `json:"reviews"`
+}
+
+// BreakerState 是熔断器需要跨重启保留的状态
+type BreakerState struct {
+	Tripped   bool      `json:"tripped"`
+	TrippedAt time.Time `json:"tripped_at"`
+	Reason    string    `json:"reason"`
+	Peak      float64   `json:"peak"`      // 观测到的账户价值峰值
+	Day       string    `json:"day"`       // 当前交易日 (UTC, 2006-01-02)
+	DayStart  float64   `json:"day_start"` // 当前交易日的第一个账户价值
+}
+
+// Usage 是一个模型在一次 RunAnalysis 中 (含修正重试) 的 token 用量与耗时
+type Usage struct {
+	Model            string        `json:"model"`
+	Requests         int           `json:"requests"`          // 实际发出的请求数 (1 + 修正次数 + 工具调用轮数)
+	PromptTokens     int64         `json:"prompt_tokens"`     // 含 CachedTokens
+	CompletionTokens int64         `json:"completion_tokens"` // 含 ReasoningTokens
+	ReasoningTokens  int64         `json:"reasoning_tokens"`
+	CachedTokens     int64         `json:"cached_tokens"` // 命中缓存的输入 token
+	Latency          time.Duration `json:"latency"`
+}
+
+// Completion 是一次 LLM 请求的原始回答
+type Completion struct {
+	Model      string   `json:"model"`
+	Error      string   `json:"error,omitempty"` // 请求失败的原因，此时没有回答内容
+	Content    string   `json:"content"`
+	Reasoning  string   `json:"reasoning,omitempty"`  // 供应商单独返回的推理内容 (e.g. reasoning_content)
+	Violations []string `json:"violations,omitempty"` // 回答无法解析或违反约束的原因，非空时会要求模型修正
+}
+
+// Trace 记录一次 RunAnalysis 提交的提示词、每次请求的原始回答与每个模型的累计用量
+type Trace struct {
+	SystemPrompt string       `json:"system_prompt"`
+	UserPrompt   string       `json:"user_prompt"`
+	Completions  []Completion `json:"completions"`
+	Usage        []Usage      `json:"usage"`                // 每个被调用的模型一条，按调用顺序 (降级时最后一条为给出决策的模型)
+	Votes        []Vote       `json:"votes,omitempty"`      // 集成决策中每个成员的回答
+	ToolCalls    []ToolCall   `json:"tool_calls,omitempty"` // 模型在分析过程中调用的工具，按调用顺序
+}
+
+// ToolCall 是模型的一次工具调用
+type ToolCall struct {
+	Model     string        `json:"model"`
+	Name      string        `json:"name"`
+	Arguments string        `json:"arguments"`        // 模型给出的原始 JSON 参数
+	Result    string        `json:"result,omitempty"` // 返回给模型的 JSON
+	Error     string        `json:"error,omitempty"`  // 调用失败或超出上限的原因，此时同样会告知模型
+	Latency   time.Duration `json:"latency"`
+}
+
+// Vote 是集成决策中一个成员的回答
+type Vote struct {
+	Member   string        `json:"member"` // 模型名，同一模型采样多次时带序号 (e.g. kimi-k2#2)
+	Decision AgentDecision `json:"decision"`
+	Error    string        `json:"error,omitempty"` // 成员没有给出有效回答的原因，此时不参与投票
+}
@@ -12,6 +12,7 @@ type PromptData struct {
 	Coins          map[string]CoinData `json:"coins"`
 	Account        AccountData         `json:"account"`
 	Positions      []PositionData      `json:"positions"`
+	RecentTrades   []ClosedTrade       `json:"recent_trades"` // 最近平仓的交易，从旧到新
 }
 
 // CoinData 包含特定加密货币的市场数据
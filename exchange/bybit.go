@@ -0,0 +1,548 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	bybitBaseURL    = "https://api.bybit.com"
+	bybitRecvWindow = "5000"
+	bybitCategory   = "linear" // U 本位永续合约
+
+	// BybitCodeLeverageNotModified 是设置的杠杆与当前杠杆相同时返回的错误码
+	BybitCodeLeverageNotModified = 110043
+)
+
+// BybitClient 是 Bybit v5 REST API 的最小客户端，只覆盖 U 本位永续合约用到的接口
+type BybitClient struct {
+	baseURL   string
+	apiKey    string
+	apiSecret string
+	http      *http.Client
+}
+
+func NewBybitClient(creds Credentials) *BybitClient {
+	return &BybitClient{
+		baseURL:   strings.TrimRight(lo.Ternary(creds.BaseURL != "", creds.BaseURL, bybitBaseURL), "/"),
+		apiKey:    creds.APIKey,
+		apiSecret: creds.APISecret,
+		http:      &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// BybitError 是 Bybit 返回的业务错误 (retCode != 0)
+type BybitError struct {
+	Code    int
+	Message string
+}
+
+func (e *BybitError) Error() string {
+	return fmt.Sprintf("bybit error %d: %s", e.Code, e.Message)
+}
+
+// Number 是 Bybit 以字符串返回的数值，空字符串解析为 0
+type Number float64
+
+func (n *Number) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = Number(v)
+	return nil
+}
+
+// --- 行情 ---
+
+type BybitKline struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// Klines 返回最近 limit 根 K 线 (包含尚未收盘的当前 K 线)，从旧到新排列
+func (c *BybitClient) Klines(ctx context.Context, symbol string, interval time.Duration, limit int) ([]BybitKline, error) {
+	var res struct {
+		List [][]string `json:"list"`
+	}
+	params := url.Values{
+		"category": {bybitCategory},
+		"symbol":   {symbol},
+		"interval": {bybitInterval(interval)},
+		"limit":    {strconv.Itoa(min(limit, 1000))},
+	}
+	if err := c.get(ctx, "/v5/market/kline", params, false, &res); err != nil {
+		return nil, err
+	}
+
+	klines := make([]BybitKline, 0, len(res.List))
+	for _, row := range res.List {
+		if len(row) < 6 {
+			continue
+		}
+		start, _ := strconv.ParseInt(row[0], 10, 64)
+		values := make([]float64, 5)
+		for i := range values {
+			values[i], _ = strconv.ParseFloat(row[i+1], 64)
+		}
+		klines = append(klines, BybitKline{
+			Start:  time.UnixMilli(start),
+			Open:   values[0],
+			High:   values[1],
+			Low:    values[2],
+			Close:  values[3],
+			Volume: values[4],
+		})
+	}
+	// Bybit 按从新到旧返回
+	slices.Reverse(klines)
+	return klines, nil
+}
+
+type BybitTicker struct {
+	Symbol       string `json:"symbol"`
+	LastPrice    Number `json:"lastPrice"`
+	MarkPrice    Number `json:"markPrice"`
+	FundingRate  string `json:"fundingRate"`
+	OpenInterest Number `json:"openInterest"`
+}
+
+func (c *BybitClient) Ticker(ctx context.Context, symbol string) (BybitTicker, error) {
+	var res struct {
+		List []BybitTicker `json:"list"`
+	}
+	params := url.Values{"category": {bybitCategory}, "symbol": {symbol}}
+	if err := c.get(ctx, "/v5/market/tickers", params, false, &res); err != nil {
+		return BybitTicker{}, err
+	}
+	for _, t := range res.List {
+		if t.Symbol == symbol {
+			return t, nil
+		}
+	}
+	return BybitTicker{}, fmt.Errorf("symbol %s not found in tickers", symbol)
+}
+
+// OpenInterestHistory 返回最近 limit 个周期的持仓量 (单位为基础资产)
+func (c *BybitClient) OpenInterestHistory(ctx context.Context, symbol string, period time.Duration, limit int) ([]float64, error) {
+	var res struct {
+		List []struct {
+			OpenInterest Number `json:"openInterest"`
+		} `json:"list"`
+	}
+	params := url.Values{
+		"category":     {bybitCategory},
+		"symbol":       {symbol},
+		"intervalTime": {bybitOIInterval(period)},
+		"limit":        {strconv.Itoa(min(limit, 200))},
+	}
+	if err := c.get(ctx, "/v5/market/open-interest", params, false, &res); err != nil {
+		return nil, err
+	}
+	values := make([]float64, len(res.List))
+	for i, oi := range res.List {
+		values[i] = float64(oi.OpenInterest)
+	}
+	return values, nil
+}
+
+type BybitFundingRate struct {
+	Time time.Time
+	Rate float64
+}
+
+// FundingHistory 返回最近 limit 次结算的资金费率，从旧到新排列
+func (c *BybitClient) FundingHistory(ctx context.Context, symbol string, limit int) ([]BybitFundingRate, error) {
+	var res struct {
+		List []struct {
+			FundingRate          Number `json:"fundingRate"`
+			FundingRateTimestamp string `json:"fundingRateTimestamp"`
+		} `json:"list"`
+	}
+	params := url.Values{
+		"category": {bybitCategory},
+		"symbol":   {symbol},
+		"limit":    {strconv.Itoa(min(limit, 200))},
+	}
+	if err := c.get(ctx, "/v5/market/funding/history", params, false, &res); err != nil {
+		return nil, err
+	}
+	rates := make([]BybitFundingRate, len(res.List))
+	for i, r := range res.List {
+		ts, _ := strconv.ParseInt(r.FundingRateTimestamp, 10, 64)
+		rates[i] = BybitFundingRate{Time: time.UnixMilli(ts), Rate: float64(r.FundingRate)}
+	}
+	// Bybit 按从新到旧返回
+	slices.Reverse(rates)
+	return rates, nil
+}
+
+// BybitOrderBook 是盘口快照，每一档为 [价格, 数量]，买盘从高到低、卖盘从低到高
+type BybitOrderBook struct {
+	Bids [][2]Number `json:"b"`
+	Asks [][2]Number `json:"a"`
+}
+
+// OrderBook 返回买卖各 depth 档的盘口
+func (c *BybitClient) OrderBook(ctx context.Context, symbol string, depth int) (BybitOrderBook, error) {
+	var res BybitOrderBook
+	params := url.Values{
+		"category": {bybitCategory},
+		"symbol":   {symbol},
+		"limit":    {strconv.Itoa(min(depth, 500))},
+	}
+	if err := c.get(ctx, "/v5/market/orderbook", params, false, &res); err != nil {
+		return BybitOrderBook{}, err
+	}
+	return res, nil
+}
+
+// Instruments 返回所有 U 本位永续合约的下单规则，key 为交易对
+func (c *BybitClient) Instruments(ctx context.Context) (map[string]SymbolFilter, error) {
+	filters := make(map[string]SymbolFilter)
+	cursor := ""
+	for {
+		var res struct {
+			List []struct {
+				Symbol        string `json:"symbol"`
+				LotSizeFilter struct {
+					QtyStep          string `json:"qtyStep"`
+					MinNotionalValue Number `json:"minNotionalValue"`
+				} `json:"lotSizeFilter"`
+				PriceFilter struct {
+					TickSize string `json:"tickSize"`
+				} `json:"priceFilter"`
+			} `json:"list"`
+			NextPageCursor string `json:"nextPageCursor"`
+		}
+		params := url.Values{"category": {bybitCategory}, "limit": {"1000"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		if err := c.get(ctx, "/v5/market/instruments-info", params, false, &res); err != nil {
+			return nil, err
+		}
+		for _, s := range res.List {
+			filters[s.Symbol] = SymbolFilter{
+				QuantityPrecision: Precision(s.LotSizeFilter.QtyStep),
+				PricePrecision:    Precision(s.PriceFilter.TickSize),
+				MinNotional:       float64(s.LotSizeFilter.MinNotionalValue),
+			}
+		}
+		if res.NextPageCursor == "" || len(res.List) == 0 {
+			return filters, nil
+		}
+		cursor = res.NextPageCursor
+	}
+}
+
+// --- 账户与持仓 ---
+
+type BybitWallet struct {
+	TotalEquity           Number `json:"totalEquity"`
+	TotalMarginBalance    Number `json:"totalMarginBalance"`
+	TotalAvailableBalance Number `json:"totalAvailableBalance"`
+}
+
+// Wallet 返回统一交易账户 (UTA) 的余额
+func (c *BybitClient) Wallet(ctx context.Context) (BybitWallet, error) {
+	var res struct {
+		List []BybitWallet `json:"list"`
+	}
+	params := url.Values{"accountType": {"UNIFIED"}}
+	if err := c.get(ctx, "/v5/account/wallet-balance", params, true, &res); err != nil {
+		return BybitWallet{}, err
+	}
+	if len(res.List) == 0 {
+		return BybitWallet{}, fmt.Errorf("wallet balance is empty")
+	}
+	return res.List[0], nil
+}
+
+type BybitPosition struct {
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"` // Buy / Sell, 无持仓时为空
+	Size          Number `json:"size"`
+	AvgPrice      Number `json:"avgPrice"`
+	MarkPrice     Number `json:"markPrice"`
+	LiqPrice      Number `json:"liqPrice"`
+	UnrealisedPnl Number `json:"unrealisedPnl"`
+	Leverage      Number `json:"leverage"`
+	PositionValue Number `json:"positionValue"`
+	TakeProfit    Number `json:"takeProfit"`
+	StopLoss      Number `json:"stopLoss"`
+}
+
+// Positions 返回 symbol 的持仓，symbol 为空时返回所有 USDT 结算的持仓
+func (c *BybitClient) Positions(ctx context.Context, symbol string) ([]BybitPosition, error) {
+	var res struct {
+		List []BybitPosition `json:"list"`
+	}
+	params := url.Values{"category": {bybitCategory}, "limit": {"200"}}
+	if symbol != "" {
+		params.Set("symbol", symbol)
+	} else {
+		params.Set("settleCoin", "USDT")
+	}
+	if err := c.get(ctx, "/v5/position/list", params, true, &res); err != nil {
+		return nil, err
+	}
+	return res.List, nil
+}
+
+// --- 交易 ---
+
+type BybitOrderRequest struct {
+	Symbol      string `json:"symbol"`
+	Side        string `json:"side"`      // Buy / Sell
+	OrderType   string `json:"orderType"` // Market / Limit
+	Qty         string `json:"qty"`
+	ReduceOnly  bool   `json:"reduceOnly,omitempty"`
+	TakeProfit  string `json:"takeProfit,omitempty"`
+	StopLoss    string `json:"stopLoss,omitempty"`
+	TpTriggerBy string `json:"tpTriggerBy,omitempty"`
+	SlTriggerBy string `json:"slTriggerBy,omitempty"`
+	TpslMode    string `json:"tpslMode,omitempty"`
+}
+
+func (c *BybitClient) SetLeverage(ctx context.Context, symbol string, leverage int) error {
+	lev := strconv.Itoa(leverage)
+	return c.post(ctx, "/v5/position/set-leverage", map[string]string{
+		"category":     bybitCategory,
+		"symbol":       symbol,
+		"buyLeverage":  lev,
+		"sellLeverage": lev,
+	}, nil)
+}
+
+// CreateOrder 下单并返回订单 ID
+func (c *BybitClient) CreateOrder(ctx context.Context, order BybitOrderRequest) (string, error) {
+	var res struct {
+		OrderID string `json:"orderId"`
+	}
+	body := struct {
+		Category string `json:"category"`
+		BybitOrderRequest
+	}{bybitCategory, order}
+	if err := c.post(ctx, "/v5/order/create", body, &res); err != nil {
+		return "", err
+	}
+	return res.OrderID, nil
+}
+
+func (c *BybitClient) CancelAllOrders(ctx context.Context, symbol string) error {
+	return c.post(ctx, "/v5/order/cancel-all", map[string]string{
+		"category": bybitCategory,
+		"symbol":   symbol,
+	}, nil)
+}
+
+type BybitOrder struct {
+	OrderID       string `json:"orderId"`
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	OrderType     string `json:"orderType"`
+	OrderStatus   string `json:"orderStatus"`
+	AvgPrice      Number `json:"avgPrice"`
+	ReduceOnly    bool   `json:"reduceOnly"`
+	CreateType    string `json:"createType"`    // e.g. CreateByUser, CreateByStopLoss, CreateByTakeProfit, CreateByLiq
+	StopOrderType string `json:"stopOrderType"` // e.g. StopLoss, TakeProfit
+	UpdatedTime   Number `json:"updatedTime"`   // 毫秒时间戳
+}
+
+// OrderHistory 返回 symbol 在 [from, to] 内的全部历史订单。
+// 与 Transactions 一样，接口单次查询的时间跨度最长为 7 天，更长的区间会被拆分为多次查询，每段按游标翻页。
+func (c *BybitClient) OrderHistory(ctx context.Context, symbol string, from, to time.Time) ([]BybitOrder, error) {
+	const maxSpan = 7 * 24 * time.Hour
+	var all []BybitOrder
+	for start := from; start.Before(to); start = start.Add(maxSpan) {
+		end := start.Add(maxSpan)
+		if end.After(to) {
+			end = to
+		}
+		cursor := ""
+		for {
+			var res struct {
+				List           []BybitOrder `json:"list"`
+				NextPageCursor string       `json:"nextPageCursor"`
+			}
+			params := url.Values{
+				"category":  {bybitCategory},
+				"symbol":    {symbol},
+				"startTime": {strconv.FormatInt(start.UnixMilli(), 10)},
+				"endTime":   {strconv.FormatInt(end.UnixMilli(), 10)},
+				"limit":     {"50"},
+			}
+			if cursor != "" {
+				params.Set("cursor", cursor)
+			}
+			if err := c.get(ctx, "/v5/order/history", params, true, &res); err != nil {
+				return nil, err
+			}
+			all = append(all, res.List...)
+			if res.NextPageCursor == "" || len(res.List) == 0 {
+				break
+			}
+			cursor = res.NextPageCursor
+		}
+	}
+	return all, nil
+}
+
+type BybitTransaction struct {
+	Symbol   string `json:"symbol"`
+	Type     string `json:"type"`     // TRADE / SETTLEMENT / ...
+	CashFlow Number `json:"cashFlow"` // 已实现盈亏
+	Funding  Number `json:"funding"`  // 资金费，正数为支出
+	Fee      Number `json:"fee"`      // 手续费，正数为支出
+}
+
+// Transactions 返回 [from, to] 内的 USDT 资金流水。
+// 接口单次查询的时间跨度最长为 7 天，更长的区间会被拆分为多次查询。
+func (c *BybitClient) Transactions(ctx context.Context, from, to time.Time) ([]BybitTransaction, error) {
+	const maxSpan = 7 * 24 * time.Hour
+	var all []BybitTransaction
+	for start := from; start.Before(to); start = start.Add(maxSpan) {
+		end := start.Add(maxSpan)
+		if end.After(to) {
+			end = to
+		}
+		cursor := ""
+		for {
+			var res struct {
+				List           []BybitTransaction `json:"list"`
+				NextPageCursor string             `json:"nextPageCursor"`
+			}
+			params := url.Values{
+				"accountType": {"UNIFIED"},
+				"category":    {bybitCategory},
+				"currency":    {"USDT"},
+				"startTime":   {strconv.FormatInt(start.UnixMilli(), 10)},
+				"endTime":     {strconv.FormatInt(end.UnixMilli(), 10)},
+				"limit":       {"50"},
+			}
+			if cursor != "" {
+				params.Set("cursor", cursor)
+			}
+			if err := c.get(ctx, "/v5/account/transaction-log", params, true, &res); err != nil {
+				return nil, err
+			}
+			all = append(all, res.List...)
+			if res.NextPageCursor == "" || len(res.List) == 0 {
+				break
+			}
+			cursor = res.NextPageCursor
+		}
+	}
+	return all, nil
+}
+
+// --- HTTP ---
+
+func (c *BybitClient) get(ctx context.Context, path string, params url.Values, signed bool, out any) error {
+	return c.do(ctx, http.MethodGet, path, params.Encode(), nil, signed, out)
+}
+
+func (c *BybitClient) post(ctx context.Context, path string, body any, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path, "", payload, true, out)
+}
+
+func (c *BybitClient) do(ctx context.Context, method, path, query string, body []byte, signed bool, out any) error {
+	endpoint := c.baseURL + path
+	if query != "" {
+		endpoint += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if signed {
+		// 签名: HMAC_SHA256(timestamp + apiKey + recvWindow + queryString | jsonBody)
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		mac := hmac.New(sha256.New, []byte(c.apiSecret))
+		mac.Write([]byte(timestamp + c.apiKey + bybitRecvWindow + query + string(body)))
+		req.Header.Set("X-BAPI-API-KEY", c.apiKey)
+		req.Header.Set("X-BAPI-TIMESTAMP", timestamp)
+		req.Header.Set("X-BAPI-RECV-WINDOW", bybitRecvWindow)
+		req.Header.Set("X-BAPI-SIGN", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bybit %s %s: http %d: %s", method, path, resp.StatusCode, raw)
+	}
+
+	var envelope struct {
+		RetCode int             `json:"retCode"`
+		RetMsg  string          `json:"retMsg"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("bybit %s %s: invalid response: %w", method, path, err)
+	}
+	if envelope.RetCode != 0 {
+		return &BybitError{Code: envelope.RetCode, Message: envelope.RetMsg}
+	}
+	if out == nil || len(envelope.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+// bybitInterval 将 K 线周期转换为 Bybit 的 interval 参数 (分钟数，日线为 "D")
+func bybitInterval(d time.Duration) string {
+	if d >= 24*time.Hour {
+		return "D"
+	}
+	return strconv.Itoa(int(d.Minutes()))
+}
+
+// bybitOIInterval 将 Binance 风格的周期 ("5m", "1h") 对应的时长转换为 Bybit 的 intervalTime 参数
+func bybitOIInterval(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return "1d"
+	case d >= time.Hour:
+		return strconv.Itoa(int(d.Hours())) + "h"
+	default:
+		return strconv.Itoa(int(d.Minutes())) + "min"
+	}
+}
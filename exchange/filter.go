@@ -0,0 +1,50 @@
+package exchange
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SymbolFilter 是交易对的下单规则，用于格式化价格与数量
+type SymbolFilter struct {
+	QuantityPrecision int     // 数量精度 (e.g., 3 -> 0.001)
+	PricePrecision    int     // 价格精度 (e.g., 2 -> 0.01)
+	MinNotional       float64 // 最小名义价值，0 表示未知
+}
+
+func (f SymbolFilter) FormatPrice(price float64) string {
+	return fmt.Sprintf("%.*f", f.PricePrecision, price)
+}
+
+func (f SymbolFilter) FormatQuantity(quantity float64) string {
+	return fmt.Sprintf("%.*f", f.QuantityPrecision, quantity)
+}
+
+// FormatPrice 使用 filters 中 symbol 的精度格式化价格，没有精度规则时原样输出
+func FormatPrice(filters map[string]SymbolFilter, symbol string, price float64) string {
+	if f, ok := filters[symbol]; ok {
+		return f.FormatPrice(price)
+	}
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// FormatQuantity 使用 filters 中 symbol 的精度格式化数量，没有精度规则时原样输出
+func FormatQuantity(filters map[string]SymbolFilter, symbol string, quantity float64) string {
+	if f, ok := filters[symbol]; ok {
+		return f.FormatQuantity(quantity)
+	}
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+// Precision 将 "0.001" 这样的步长字符串转换为 3 (小数位数)
+func Precision(stepOrTickSize string) int {
+	// 去掉末尾的 0，例如 "0.0100" -> "0.01"
+	trimmed := strings.TrimRight(stepOrTickSize, "0")
+	parts := strings.Split(trimmed, ".")
+	if len(parts) == 2 {
+		// e.g., "0.01" -> "01", 长度为 2
+		return len(parts[1])
+	}
+	return 0 // 没有小数点 (e.g., "1"), 精度为 0
+}
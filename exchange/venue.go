@@ -0,0 +1,42 @@
+package exchange
+
+import "strings"
+
+// Venue 描述一个交易所上币种与 U 本位永续合约交易对之间的映射
+type Venue struct {
+	Name    string
+	Quote   string            // 计价货币后缀, e.g. "USDT"
+	Aliases map[string]string // 币种在该交易所使用的基础资产名, e.g. "PEPE" → "1000PEPE"
+}
+
+var (
+	Binance = Venue{Name: "Binance", Quote: "USDT"}
+	Bybit   = Venue{Name: "Bybit", Quote: "USDT"}
+)
+
+// Symbol 将币种 (e.g. "BTC") 转换为交易对 (e.g. "BTCUSDT")
+func (v Venue) Symbol(coin string) string {
+	coin = strings.ToUpper(coin)
+	if base, ok := v.Aliases[coin]; ok {
+		coin = base
+	}
+	return coin + v.Quote
+}
+
+// Coin 是 Symbol 的逆操作
+func (v Venue) Coin(symbol string) string {
+	base := strings.TrimSuffix(symbol, v.Quote)
+	for coin, alias := range v.Aliases {
+		if alias == base {
+			return coin
+		}
+	}
+	return base
+}
+
+// Credentials 是连接交易所所需的密钥与地址
+type Credentials struct {
+	APIKey    string
+	APISecret string
+	BaseURL   string // 为空时使用交易所的默认地址，测试时可指向本地的 mock 服务
+}
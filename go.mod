@@ -10,6 +10,7 @@ require (
 	github.com/openai/openai-go/v2 v2.7.1
 	github.com/samber/lo v1.52.0
 	golang.org/x/sync v0.11.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
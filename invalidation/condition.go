@@ -0,0 +1,321 @@
+package invalidation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/samber/lo"
+)
+
+// ErrMissingMetric 表示求值时缺少条件引用的指标 (e.g. 资金费率暂时无法获取)
+var ErrMissingMetric = errors.New("metric unavailable")
+
+// Condition 是解析后的失效条件。
+//
+// 语法:
+//
+//	expr       = and { ("or" | "||") and }
+//	and        = term { ("and" | "&&") term }
+//	term       = "(" expr ")" | operand op operand | operand "crosses" ("above" | "below") operand
+//	op         = "<" | "<=" | ">" | ">="
+//	operand    = 数值 | 指标名
+//
+// 例如 "price < 98500"、"rsi7_5m > 80 or funding < 0"、"price crosses below ema20_4h"。
+// crosses 需要与上一次求值比较，因此 Condition 是有状态的，不能并发使用。
+type Condition struct {
+	text string
+	root node
+}
+
+// Parse 解析失效条件，关键字与指标名不区分大小写，未知的指标名视为错误
+func Parse(text string) (*Condition, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at the end of condition", p.tokens[p.pos])
+	}
+	return &Condition{text: text, root: root}, nil
+}
+
+func (c *Condition) String() string {
+	return c.text
+}
+
+// Eval 用 metrics 求值，成立时返回使条件成立的子条件 (用于日志)。
+// 缺少引用的指标时返回 ErrMissingMetric；crosses 在第一次求值时没有上一次的值，总是不成立。
+func (c *Condition) Eval(metrics map[string]float64) (bool, string, error) {
+	return c.root.eval(metrics)
+}
+
+type node interface {
+	eval(metrics map[string]float64) (bool, string, error)
+}
+
+type orNode []node
+
+func (n orNode) eval(metrics map[string]float64) (bool, string, error) {
+	var errs []error
+	for _, child := range n {
+		ok, fired, err := child.eval(metrics)
+		if err != nil {
+			errs = append(errs, err) // 其他分支成立时仍然触发
+			continue
+		}
+		if ok {
+			return true, fired, nil
+		}
+	}
+	return false, "", errors.Join(errs...)
+}
+
+type andNode []node
+
+func (n andNode) eval(metrics map[string]float64) (bool, string, error) {
+	fired := make([]string, 0, len(n))
+	for _, child := range n {
+		ok, f, err := child.eval(metrics)
+		if err != nil || !ok {
+			return false, "", err
+		}
+		fired = append(fired, f)
+	}
+	return true, strings.Join(fired, " and "), nil
+}
+
+// operand 是数值或指标名
+type operand struct {
+	metric string // 为空时为常数 value
+	value  float64
+}
+
+func (o operand) resolve(metrics map[string]float64) (float64, error) {
+	if o.metric == "" {
+		return o.value, nil
+	}
+	v, ok := metrics[o.metric]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrMissingMetric, o.metric)
+	}
+	return v, nil
+}
+
+func (o operand) String() string {
+	if o.metric == "" {
+		return strconv.FormatFloat(o.value, 'f', -1, 64)
+	}
+	return o.metric
+}
+
+type compareNode struct {
+	left, right operand
+	op          string
+}
+
+func (n *compareNode) eval(metrics map[string]float64) (bool, string, error) {
+	l, err := n.left.resolve(metrics)
+	if err != nil {
+		return false, "", err
+	}
+	r, err := n.right.resolve(metrics)
+	if err != nil {
+		return false, "", err
+	}
+	var ok bool
+	switch n.op {
+	case "<":
+		ok = l < r
+	case "<=":
+		ok = l <= r
+	case ">":
+		ok = l > r
+	case ">=":
+		ok = l >= r
+	}
+	return ok, fmt.Sprintf("%s %s %s (%g %s %g)", n.left, n.op, n.right, l, n.op, r), nil
+}
+
+type crossNode struct {
+	left, right operand
+	above       bool
+	prev        *float64 // 上一次求值时 left - right 的值
+}
+
+func (n *crossNode) eval(metrics map[string]float64) (bool, string, error) {
+	l, err := n.left.resolve(metrics)
+	if err != nil {
+		return false, "", err
+	}
+	r, err := n.right.resolve(metrics)
+	if err != nil {
+		return false, "", err
+	}
+	diff, prev := l-r, n.prev
+	n.prev = &diff
+	if prev == nil {
+		return false, "", nil
+	}
+	ok := *prev <= 0 && diff > 0
+	if !n.above {
+		ok = *prev >= 0 && diff < 0
+	}
+	direction := lo.Ternary(n.above, "above", "below")
+	return ok, fmt.Sprintf("%s crosses %s %s (%g vs %g)", n.left, direction, n.right, l, r), nil
+}
+
+// tokenize 把条件拆分为数值、标识符、比较符与括号
+func tokenize(text string) ([]string, error) {
+	var tokens []string
+	runes := []rune(strings.ToLower(text))
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '<' || c == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, string(runes[i:i+2]))
+				i += 2
+			} else {
+				tokens = append(tokens, string(c))
+				i++
+			}
+		case c == '&' || c == '|':
+			if i+1 >= len(runes) || runes[i+1] != c {
+				return nil, fmt.Errorf("unexpected %q, use %c%c", c, c, c)
+			}
+			tokens = append(tokens, string(runes[i:i+2]))
+			i += 2
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' || c == '-' || c == '+':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("_.-+", runes[j])) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty condition")
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	nodes, err := p.parseList(p.parseAnd, "or", "||")
+	if err != nil || len(nodes) == 1 {
+		return lo.FirstOrEmpty(nodes), err
+	}
+	return orNode(nodes), nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	nodes, err := p.parseList(p.parseTerm, "and", "&&")
+	if err != nil || len(nodes) == 1 {
+		return lo.FirstOrEmpty(nodes), err
+	}
+	return andNode(nodes), nil
+}
+
+// parseList 解析由 separators 之一连接的一个或多个 parse
+func (p *parser) parseList(parse func() (node, error), separators ...string) ([]node, error) {
+	var nodes []node
+	for {
+		n, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if !lo.Contains(separators, p.peek()) {
+			return nodes, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseTerm() (node, error) {
+	if p.peek() == "(" {
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, errors.New("missing closing parenthesis")
+		}
+		return n, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.next(); op {
+	case "<", "<=", ">", ">=":
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{left: left, right: right, op: op}, nil
+	case "crosses":
+		direction := p.next()
+		if direction != "above" && direction != "below" {
+			return nil, fmt.Errorf("expected above or below after crosses, got %q", direction)
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &crossNode{left: left, right: right, above: direction == "above"}, nil
+	case "":
+		return nil, fmt.Errorf("missing comparison after %s", left)
+	default:
+		return nil, fmt.Errorf("expected <, <=, >, >= or crosses after %s, got %q", left, op)
+	}
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	token := p.next()
+	if token == "" {
+		return operand{}, errors.New("unexpected end of condition")
+	}
+	if v, err := strconv.ParseFloat(token, 64); err == nil {
+		return operand{value: v}, nil
+	}
+	if !IsMetric(token) {
+		return operand{}, fmt.Errorf("unknown metric %q, must be a number or one of %s", token, strings.Join(MetricNames(), ", "))
+	}
+	return operand{metric: token}, nil
+}
@@ -0,0 +1,212 @@
+package invalidation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+)
+
+func setIntervals() {
+	config.App.Market.KlineInterval = 5 * time.Minute
+	config.App.Market.KlineIntervalLonger = 4 * time.Hour
+}
+
+func TestParseRejectsInvalidConditions(t *testing.T) {
+	setIntervals()
+	tests := []struct {
+		text string
+		want string // 错误信息中应包含的片段
+	}{
+		{"", "empty condition"},
+		{"price", "missing comparison"},
+		{"price <", "unexpected end"},
+		{"price is 100", "expected <, <=, >, >= or crosses"},
+		{"volume > 100", `unknown metric "volume"`},
+		{"rsi7_1h > 80", `unknown metric "rsi7_1h"`},
+		{"price < 100 & funding < 0", "use &&"},
+		{"(price < 100 or funding < 0", "missing closing parenthesis"},
+		{"price < 100)", "at the end of condition"},
+		{"price crosses ema20_4h", "expected above or below"},
+		{"price < 100 $", "unexpected character"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.text)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error containing %q", tt.text, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	setIntervals()
+	tests := []struct {
+		name    string
+		text    string
+		metrics map[string]float64
+		want    bool
+		fired   string
+		wantErr error
+	}{
+		{
+			name:    "comparison",
+			text:    "price < 98500",
+			metrics: map[string]float64{"price": 98000},
+			want:    true,
+			fired:   "price < 98500 (98000 < 98500)",
+		},
+		{
+			name:    "boundary",
+			text:    "price <= 100 and price >= 100",
+			metrics: map[string]float64{"price": 100},
+			want:    true,
+			fired:   "price <= 100 (100 <= 100) and price >= 100 (100 >= 100)",
+		},
+		{
+			name:    "case insensitive",
+			text:    "PRICE > 90 AND RSI7_5M > 80",
+			metrics: map[string]float64{"price": 100, "rsi7_5m": 85},
+			want:    true,
+			fired:   "price > 90 (100 > 90) and rsi7_5m > 80 (85 > 80)",
+		},
+		{
+			name:    "and binds tighter than or",
+			text:    "price > 5 or price < 1 and rsi7_5m > 80",
+			metrics: map[string]float64{"price": 10, "rsi7_5m": 50},
+			want:    true,
+			fired:   "price > 5 (10 > 5)",
+		},
+		{
+			name:    "parentheses override precedence",
+			text:    "(price > 5 or price < 1) and rsi7_5m > 80",
+			metrics: map[string]float64{"price": 10, "rsi7_5m": 50},
+			want:    false,
+		},
+		{
+			name:    "symbolic operators",
+			text:    "price < 1 || price > 5 && rsi7_5m > 80",
+			metrics: map[string]float64{"price": 10, "rsi7_5m": 90},
+			want:    true,
+			fired:   "price > 5 (10 > 5) and rsi7_5m > 80 (90 > 80)",
+		},
+		{
+			name:    "negative number",
+			text:    "funding < -0.0001",
+			metrics: map[string]float64{"funding": -0.0002},
+			want:    true,
+			fired:   "funding < -0.0001 (-0.0002 < -0.0001)",
+		},
+		{
+			name:    "exponent",
+			text:    "funding < -1e-4 or price > 1.5E3",
+			metrics: map[string]float64{"funding": 0, "price": 1600},
+			want:    true,
+			fired:   "price > 1500 (1600 > 1500)",
+		},
+		{
+			name:    "metric on both sides",
+			text:    "ema20_4h < ema50_4h",
+			metrics: map[string]float64{"ema20_4h": 99, "ema50_4h": 100},
+			want:    true,
+			fired:   "ema20_4h < ema50_4h (99 < 100)",
+		},
+		{
+			name:    "missing funding inside or, other branch holds",
+			text:    "funding < 0 or price < 100",
+			metrics: map[string]float64{"price": 50},
+			want:    true,
+			fired:   "price < 100 (50 < 100)",
+		},
+		{
+			name:    "missing funding inside or, other branch fails",
+			text:    "funding < 0 or price < 100",
+			metrics: map[string]float64{"price": 150},
+			want:    false,
+			wantErr: ErrMissingMetric,
+		},
+		{
+			name:    "missing funding inside and",
+			text:    "price < 100 and funding < 0",
+			metrics: map[string]float64{"price": 50},
+			want:    false,
+			wantErr: ErrMissingMetric,
+		},
+		{
+			name:    "and short-circuits before missing metric",
+			text:    "price < 100 and funding < 0",
+			metrics: map[string]float64{"price": 150},
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.text)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.text, err)
+			}
+			ok, fired, err := c.Eval(tt.metrics)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Eval error = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.want {
+				t.Errorf("Eval = %v, want %v", ok, tt.want)
+			}
+			if fired != tt.fired {
+				t.Errorf("fired = %q, want %q", fired, tt.fired)
+			}
+		})
+	}
+}
+
+// crosses 与上一次求值比较: 第一次求值总是不成立，只有从一侧穿越到另一侧的那一次成立
+func TestConditionCrossesKeepsState(t *testing.T) {
+	setIntervals()
+	tests := []struct {
+		text   string
+		prices []float64
+		want   []bool
+	}{
+		{"price crosses above 100", []float64{105, 95, 100, 101, 102, 99, 101}, []bool{false, false, false, true, false, false, true}},
+		{"price crosses below 100", []float64{95, 105, 100, 99, 98, 101, 99}, []bool{false, false, false, true, false, false, true}},
+		{"price crosses below ema20_4h or price > 200", []float64{110, 90, 250, 95}, []bool{false, true, true, true}},
+	}
+	for _, tt := range tests {
+		c, err := Parse(tt.text)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.text, err)
+		}
+		for i, price := range tt.prices {
+			ok, _, err := c.Eval(map[string]float64{"price": price, "ema20_4h": 100})
+			if err != nil {
+				t.Fatalf("%q step %d: %v", tt.text, i, err)
+			}
+			if ok != tt.want[i] {
+				t.Errorf("%q step %d (price %v) = %v, want %v", tt.text, i, price, ok, tt.want[i])
+			}
+		}
+	}
+}
+
+// 缺少指标的那一次求值不更新 crosses 的状态
+func TestConditionCrossesSkipsMissingMetric(t *testing.T) {
+	setIntervals()
+	c, err := Parse("price crosses above ema20_4h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _, err := c.Eval(map[string]float64{"price": 90, "ema20_4h": 100}); ok || err != nil {
+		t.Fatalf("first Eval = %v, %v, want false, nil", ok, err)
+	}
+	if _, _, err := c.Eval(map[string]float64{"price": 110}); !errors.Is(err, ErrMissingMetric) {
+		t.Fatalf("Eval without ema20_4h error = %v, want %v", err, ErrMissingMetric)
+	}
+	if ok, _, err := c.Eval(map[string]float64{"price": 110, "ema20_4h": 100}); !ok || err != nil {
+		t.Fatalf("Eval after crossing = %v, %v, want true, nil", ok, err)
+	}
+}
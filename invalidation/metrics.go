@@ -0,0 +1,49 @@
+package invalidation
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// metricsOf 按指标名取出 data 中的当前值，短周期与长周期指标以 K 线周期为后缀 (e.g. rsi7_5m、ema20_4h)
+func metricsOf(data entity.CoinData) map[string]float64 {
+	short := "_" + collector.IntervalString(config.App.Market.KlineInterval)
+	long := "_" + collector.IntervalString(config.App.Market.KlineIntervalLonger)
+	metrics := map[string]float64{
+		"price":         data.Price,
+		"ema20" + short: data.EMA20,
+		"macd" + short:  data.MACD,
+		"rsi7" + short:  data.RSI7,
+		"rsi14" + short: lo.LastOrEmpty(data.Intraday.Rsi143m),
+		"ema20" + long:  data.LongTerm.Ema204h,
+		"ema50" + long:  data.LongTerm.Ema504h,
+		"atr3" + long:   data.LongTerm.Atr34h,
+		"atr14" + long:  data.LongTerm.Atr144h,
+		"macd" + long:   lo.LastOrEmpty(data.LongTerm.MACD4h),
+		"rsi14" + long:  lo.LastOrEmpty(data.LongTerm.Rsi144h),
+	}
+	// 资金费率暂时无法获取时不提供，引用它的条件求值失败而不是按 0 处理
+	if funding, err := strconv.ParseFloat(data.FundRate, 64); err == nil {
+		metrics["funding"] = funding
+	}
+	return metrics
+}
+
+// MetricNames 返回条件中可以引用的全部指标名
+func MetricNames() []string {
+	var data entity.CoinData
+	data.FundRate = "0"
+	names := lo.Keys(metricsOf(data))
+	slices.Sort(names)
+	return names
+}
+
+// IsMetric 判断 name 是否为可以引用的指标名
+func IsMetric(name string) bool {
+	return slices.Contains(MetricNames(), name)
+}
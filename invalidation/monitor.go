@@ -0,0 +1,124 @@
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/entity"
+)
+
+// Positions 提供需要监控的持仓元数据，*trade.Manager 实现了该接口
+type Positions interface {
+	Symbols() []string
+	Get(symbol string) (entity.TradeMetadata, bool)
+}
+
+// CloseFunc 平掉 coin 的持仓
+type CloseFunc func(ctx context.Context, coin string) error
+
+// Monitor 在两次决策之间按固定间隔检查每个持仓的失效条件，条件成立时立即平仓
+type Monitor struct {
+	interval  time.Duration
+	market    collector.MarketQuerier
+	positions Positions
+	close     CloseFunc
+	// tracked 缓存每个持仓解析后的条件，crosses 的状态保存在其中，key 为 symbol
+	tracked map[string]*tracked
+}
+
+type tracked struct {
+	entryTime time.Time
+	text      string
+	cond      *Condition // 为 nil 时条件无法解析，不监控
+	fired     string     // 已触发但平仓失败时为触发的子条件，下次检查直接重试平仓
+}
+
+func NewMonitor(interval time.Duration, market collector.MarketQuerier, positions Positions, close CloseFunc) *Monitor {
+	return &Monitor{
+		interval:  interval,
+		market:    market,
+		positions: positions,
+		close:     close,
+		tracked:   make(map[string]*tracked),
+	}
+}
+
+// Run 按间隔检查直到 ctx 结束
+func (m *Monitor) Run(ctx context.Context) {
+	log.Printf("... 🚨 失效条件监控: 每 %s 检查一次", m.interval)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check 检查一次所有持仓的失效条件
+func (m *Monitor) Check(ctx context.Context) {
+	symbols := m.positions.Symbols()
+	for symbol := range m.tracked {
+		if !slices.Contains(symbols, symbol) {
+			delete(m.tracked, symbol)
+		}
+	}
+
+	for _, symbol := range symbols {
+		meta, ok := m.positions.Get(symbol)
+		if !ok || meta.InvalidationCondition == "" {
+			continue
+		}
+		t := m.track(meta)
+		if t.cond == nil {
+			continue
+		}
+
+		if t.fired == "" {
+			data, err := collector.Snapshot(ctx, m.market, symbol)
+			if err != nil {
+				log.Printf("⚠️ [失效条件] 无法获取 %s 的行情: %v", symbol, err)
+				continue
+			}
+			ok, fired, err := t.cond.Eval(metricsOf(data))
+			if err != nil && !errors.Is(err, ErrMissingMetric) {
+				log.Printf("⚠️ [失效条件] %s 求值失败: %v", symbol, err)
+			}
+			if !ok {
+				continue
+			}
+			t.fired = fired
+			log.Printf("🚨 [失效条件] %s 触发: %s (条件: %s)，正在平仓...", symbol, fired, t.text)
+		}
+
+		if err := m.close(ctx, symbol); err != nil {
+			log.Printf("   ... ❗ [失效条件] %s 平仓失败，下次检查时重试: %v", symbol, err)
+			continue
+		}
+		log.Printf("   ... ✅ [失效条件] %s 已平仓。", symbol)
+		delete(m.tracked, symbol)
+	}
+}
+
+// track 返回持仓当前条件的缓存，持仓被重新开仓或条件变化时重新解析
+func (m *Monitor) track(meta entity.TradeMetadata) *tracked {
+	if t, ok := m.tracked[meta.Symbol]; ok && t.entryTime.Equal(meta.EntryTime) && t.text == meta.InvalidationCondition {
+		return t
+	}
+	t := &tracked{entryTime: meta.EntryTime, text: meta.InvalidationCondition}
+	cond, err := Parse(meta.InvalidationCondition)
+	if err != nil {
+		log.Printf("⚠️ [失效条件] %s 的条件无法解析，不会被监控: %q: %v", meta.Symbol, meta.InvalidationCondition, err)
+	} else {
+		t.cond = cond
+	}
+	m.tracked[meta.Symbol] = t
+	return t
+}
@@ -0,0 +1,158 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+)
+
+// Source 提供平仓成交价与交易所结算金额，trade.Executor 满足该接口
+type Source interface {
+	CloseReason(ctx context.Context, symbol string, since time.Time) (entity.CloseReason, float64, error)
+	Settlement(ctx context.Context, symbol string, from, to time.Time) (entity.Settlement, error)
+}
+
+// Journal 是已平仓交易的日志，以 JSON Lines 的形式追加写入 path
+type Journal struct {
+	source Source
+	path   string // 为空时只保存在内存中
+
+	mu     sync.Mutex
+	trades []entity.ClosedTrade
+}
+
+// New 从 path 加载已有的交易日志，path 为空时返回一个纯内存的实例
+func New(path string, source Source) *Journal {
+	j := &Journal{source: source, path: path}
+	if path == "" {
+		return j
+	}
+	trades, err := load(path)
+	if err != nil {
+		log.Printf("⚠️ [交易日志] 无法读取 %s: %v", path, err)
+	}
+	j.trades = trades
+	return j
+}
+
+// NewSnapshot 加载 path 中已有的交易日志，之后记录的交易只保存在内存中
+func NewSnapshot(path string, source Source) *Journal {
+	j := New(path, source)
+	j.path = ""
+	return j
+}
+
+// Record 为 closed 补全成交价与结算金额后写入日志。
+// 结算金额查询失败时仍会记录交易，对应字段为 0。
+func (j *Journal) Record(ctx context.Context, closed entity.ClosedPosition) entity.ClosedTrade {
+	trade := entity.ClosedTrade{
+		Coin:                  closed.Symbol,
+		Side:                  closed.Side,
+		Leverage:              closed.Leverage,
+		Quantity:              closed.Quantity,
+		EntryTime:             closed.EntryTime,
+		ExitTime:              closed.ClosedAt,
+		EntryPrice:            closed.EntryPrice,
+		ExitPrice:             closed.ExitPrice,
+		Justification:         closed.Justification,
+		InvalidationCondition: closed.InvalidationCondition,
+		Confidence:            closed.Confidence,
+		Reason:                closed.Reason,
+		Adopted:               closed.Adopted,
+	}
+
+	if trade.ExitPrice == 0 {
+		if _, price, err := j.source.CloseReason(ctx, closed.Symbol, closed.EntryTime); err != nil {
+			log.Printf("⚠️ [交易日志] 无法查询 %s 的平仓成交价: %v", closed.Symbol, err)
+		} else {
+			trade.ExitPrice = price
+		}
+	}
+	// 开仓手续费在记录 EntryTime 之前就已产生，向前多取一分钟
+	settlement, err := j.source.Settlement(ctx, closed.Symbol, closed.EntryTime.Add(-time.Minute), utils.Now())
+	if err != nil {
+		log.Printf("⚠️ [交易日志] 无法查询 %s 的结算金额: %v", closed.Symbol, err)
+	}
+	trade.Settlement = settlement
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.trades = append(j.trades, trade)
+	if err := j.append(trade); err != nil {
+		log.Printf("⚠️ [交易日志] 无法写入 %s: %v", j.path, err)
+	}
+	log.Printf("📒 [交易日志] %s %s 已平仓 (%s): 已实现盈亏 %.4f, 手续费 %.4f, 资金费 %.4f, 净盈亏 %.4f",
+		trade.Coin, trade.Side, trade.Reason, trade.RealizedPnL, trade.Commission, trade.Funding, trade.NetPnL)
+	return trade
+}
+
+// Query 返回平仓时间在 [from, to] 内的交易，按平仓时间从旧到新排列。
+// coin 为空时不按币种过滤，from / to 为零值时不限制对应的边界。
+func (j *Journal) Query(coin string, from, to time.Time) []entity.ClosedTrade {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return lo.Filter(j.trades, func(t entity.ClosedTrade, _ int) bool {
+		return (coin == "" || strings.EqualFold(t.Coin, coin)) &&
+			(from.IsZero() || !t.ExitTime.Before(from)) &&
+			(to.IsZero() || !t.ExitTime.After(to))
+	})
+}
+
+// Recent 返回最近平仓的 n 笔交易，从旧到新排列
+func (j *Journal) Recent(n int) []entity.ClosedTrade {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if n <= 0 {
+		return nil
+	}
+	return append([]entity.ClosedTrade(nil), j.trades[max(len(j.trades)-n, 0):]...)
+}
+
+// append 把一条记录追加到日志文件，调用方需持有 j.mu
+func (j *Journal) append(trade entity.ClosedTrade) error {
+	if j.path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(trade)
+}
+
+func load(path string) ([]entity.ClosedTrade, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var trades []entity.ClosedTrade
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var trade entity.ClosedTrade
+		if err := json.Unmarshal(scanner.Bytes(), &trade); err != nil {
+			return trades, fmt.Errorf("line %d: %w", line, err)
+		}
+		trades = append(trades, trade)
+	}
+	return trades, scanner.Err()
+}
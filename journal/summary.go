@@ -0,0 +1,45 @@
+package journal
+
+import (
+	"log"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// Summary 是一组交易的汇总
+type Summary struct {
+	Trades      int
+	Wins        int
+	RealizedPnL float64
+	Commission  float64
+	Funding     float64
+	NetPnL      float64
+}
+
+func Summarize(trades []entity.ClosedTrade) Summary {
+	s := Summary{Trades: len(trades)}
+	for _, t := range trades {
+		s.RealizedPnL += t.RealizedPnL
+		s.Commission += t.Commission
+		s.Funding += t.Funding
+		s.NetPnL += t.NetPnL
+	}
+	s.Wins = lo.CountBy(trades, func(t entity.ClosedTrade) bool { return t.NetPnL > 0 })
+	return s
+}
+
+func (s Summary) WinRate() float64 {
+	if s.Trades == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Trades)
+}
+
+func (s Summary) Log() {
+	log.Println("📒 [交易日志] ===== 汇总 =====")
+	log.Printf("   ... 交易笔数: %d, 胜率: %.2f%%", s.Trades, s.WinRate()*100)
+	log.Printf("   ... 已实现盈亏: $%.2f", s.RealizedPnL)
+	log.Printf("   ... 手续费: $%.2f, 资金费: $%.2f", s.Commission, s.Funding)
+	log.Printf("   ... 净盈亏: $%.2f", s.NetPnL)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/journal"
+)
+
+// runJournal 按 -coin、-from、-to 过滤并输出交易日志 (与 -paper / -shadow 组合时输出对应模式的日志)
+func runJournal(_ context.Context, args []string) error {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+	coin := fs.String("coin", "", "按币种过滤 (e.g. BTC)")
+	rawFrom := fs.String("from", "", "平仓时间下界 (UTC, 2006-01-02 或 2006-01-02 15:04:05)")
+	rawTo := fs.String("to", "", "平仓时间上界 (UTC, 格式同 -from，包含在内，只有日期时包含当天全天)")
+	fs.Parse(args)
+
+	from, err := parseBacktestTime(*rawFrom)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to, err := parseEndTime(*rawTo)
+	if err != nil {
+		return fmt.Errorf("invalid -to: %w", err)
+	}
+
+	path := statePaths(config.App).Journal
+	trades := journal.New(path, nil).Query(*coin, from, to)
+	for _, t := range trades {
+		log.Printf("%s → %s %-5s %-5s %2dx 数量 %f, %.4f → %.4f, %-12s 已实现 %9.4f, 手续费 %8.4f, 资金费 %8.4f, 净盈亏 %9.4f",
+			t.EntryTime.Format(time.DateTime), t.ExitTime.Format(time.DateTime), t.Coin, t.Side, t.Leverage, t.Quantity,
+			t.EntryPrice, t.ExitPrice, t.Reason, t.RealizedPnL, t.Commission, t.Funding, t.NetPnL)
+	}
+	journal.Summarize(trades).Log()
+	return nil
+}
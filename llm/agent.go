@@ -2,84 +2,301 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gtoxlili/echoAlpha/config"
 	"github.com/gtoxlili/echoAlpha/entity"
 	"github.com/gtoxlili/echoAlpha/prompts"
 	"github.com/gtoxlili/echoAlpha/utils"
 
+	json "github.com/bytedance/sonic"
 	"github.com/openai/openai-go/v2"
-	"github.com/openai/openai-go/v2/shared"
 	"github.com/samber/lo"
 )
 
 type Agent struct {
-	client                openai.Client
-	model                 string
+	backends              []backend // 按降级顺序排列，第一个为主模型
 	systemPrompt          string
 	lastPortfolioAnalysis string
+	persistence           *config.Persistence
+	rules                 ValidationRules
+	// 工具调用模式，tools 为空时不发送 tools
+	tools        map[string]Tool
+	toolParams   []openai.ChatCompletionToolUnionParam
+	maxToolCalls int
 }
 
-func NewAgent(exchange string, coins []string, modelName string, startingCapital float64) (*Agent, error) {
+// backend 是 Agent 可以调用的一个模型
+type backend struct {
+	model          string
+	provider       config.ProviderConfig
+	completions    completer
+	responseFormat openai.ChatCompletionNewParamsResponseFormatUnion
+}
+
+// Option 用于定制 Agent 的可选行为
+type Option func(*Agent)
+
+// WithPersistence 指定组合分析的持久化位置，默认只保存在内存中
+func WithPersistence(p *config.Persistence) Option {
+	return func(a *Agent) {
+		a.persistence = p
+	}
+}
+
+// NewAgent 创建使用 modelName 的 Agent。
+// modelName 为 model.name 时，model.fallbacks 中的模型依次作为降级备选，使用与主模型相同的提示词。
+func NewAgent(exchange string, coins []string, modelName string, startingCapital float64, opts ...Option) (*Agent, error) {
+	models := []string{modelName}
+	if config.App.Model.Name == modelName {
+		models = append(models, config.App.Model.Fallbacks...)
+	}
+	if len(models) > 1 {
+		log.Printf("... 降级顺序: %s", strings.Join(models, " → "))
+	}
+	return newAgent(exchange, coins, modelName, models, startingCapital, opts...)
+}
+
+// newAgent 创建按 models 的顺序使用各模型的 Agent，name 为系统提示词中的模型名
+func newAgent(exchange string, coins []string, name string, models []string, startingCapital float64, opts ...Option) (*Agent, error) {
 	systemPrompt := prompts.BuildSystemPrompt(
 		exchange,
 		coins,
-		modelName,
+		name,
 		startingCapital,
-		config.DecisionFrequency,
-		config.MinLeverage,
-		config.MaxLeverage,
+		config.App.Trading.DecisionFrequency,
+		config.App.Trading.MinLeverage,
+		config.App.Trading.MaxLeverage,
 	)
 
-	client, err := resolveClient(modelName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OpenAI client: %w", err)
+	backends := make([]backend, 0, len(models))
+	for _, model := range models {
+		b, err := newBackend(model, "agent_decision", decisionSchema(coins))
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+
+	agent := &Agent{
+		backends:     backends,
+		systemPrompt: systemPrompt,
+		persistence:  config.NewPersistence(""),
+		rules: ValidationRules{
+			Coins:       coins,
+			MinLeverage: config.App.Trading.MinLeverage,
+			MaxLeverage: config.App.Trading.MaxLeverage,
+			MinNotional: config.App.Risk.MinNotionalUSD,
+		},
 	}
+	for _, opt := range opts {
+		opt(agent)
+	}
+	agent.lastPortfolioAnalysis = agent.persistence.PortfolioAnalysis
+
+	return agent, nil
+}
 
-	return &Agent{
-		client:                client,
-		model:                 modelName,
-		systemPrompt:          systemPrompt,
-		lastPortfolioAnalysis: config.AppPersistence.PortfolioAnalysis,
+// newBackend 创建调用 model 的 backend，回答按 schema 约束 (供应商支持 json_schema 时)
+func newBackend(model string, schemaName string, schema map[string]any) (backend, error) {
+	provider, err := resolveProvider(model)
+	if err != nil {
+		return backend{}, err
+	}
+	client, err := resolveClient(provider)
+	if err != nil {
+		return backend{}, fmt.Errorf("failed to create OpenAI client for %s: %w", model, err)
+	}
+	return backend{
+		model:          model,
+		provider:       provider,
+		completions:    newCassette(&client.Chat.Completions, config.App.Model.Cassette),
+		responseFormat: resolveResponseFormat(model, provider, schemaName, schema),
 	}, nil
 }
 
+// newParams 返回以 system、user 开始对话的请求参数，采样参数按供应商的设置
+func (b backend) newParams(system, user string) openai.ChatCompletionNewParams {
+	param := openai.ChatCompletionNewParams{
+		Model: b.model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(system),
+			openai.UserMessage(user),
+		},
+		ResponseFormat:  b.responseFormat,
+		ReasoningEffort: openai.ReasoningEffort(b.provider.ReasoningEffort), // 为空时不发送
+	}
+	if b.provider.MaxTokens > 0 {
+		param.MaxTokens = openai.Int(int64(b.provider.MaxTokens))
+	}
+	if !b.provider.FixedTemperature {
+		param.Temperature = openai.Float(config.App.Model.Temperature)
+	}
+	return param
+}
+
+// Prompts 返回本次分析将提交给 LLM 的系统提示词与用户提示词
+func (a *Agent) Prompts(data entity.PromptData) (system, user string) {
+	return a.systemPrompt, prompts.BuildUserPrompt(data, a.lastPortfolioAnalysis)
+}
+
+// RunAnalysis 请求模型给出决策，返回的决策以 Model 标明由哪个模型给出。
+// 主模型出错或没有在 ctx 剩余时间的 model.fallback_budget 比例内给出有效回答时，
+// 用相同的提示词依次降级到 model.fallbacks 中的下一个模型，最后一个模型可以使用全部剩余时间。
+// 无论成功与否都会返回提示词、每次请求的原始回答以及每个模型的 token 用量与耗时。
 func (a *Agent) RunAnalysis(
 	ctx context.Context,
 	data entity.PromptData,
-) (entity.AgentDecision, error) {
-	userPrompt := prompts.BuildUserPrompt(data, a.lastPortfolioAnalysis)
+) (entity.AgentDecision, entity.Trace, error) {
+	systemPrompt, userPrompt := a.Prompts(data)
+	trace := entity.Trace{SystemPrompt: systemPrompt, UserPrompt: userPrompt}
 
-	param := openai.ChatCompletionNewParams{
-		Model: a.model,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(a.systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
-		MaxTokens:   openai.Int(1024 * 32),
-		Temperature: openai.Float(config.LLMTemperature),
-		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
-			OfJSONObject: lo.ToPtr(shared.NewResponseFormatJSONObjectParam()),
-		},
-		ReasoningEffort: openai.ReasoningEffortHigh,
+	var errs []error
+	for i, b := range a.backends {
+		last := i == len(a.backends)-1
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok && !last {
+			attemptCtx, cancel = context.WithTimeout(ctx, time.Duration(float64(time.Until(deadline))*config.App.Model.FallbackBudget))
+		}
+		decision, usage, err := a.analyze(attemptCtx, b, data, &trace)
+		cancel()
+		trace.Usage = append(trace.Usage, usage)
+		if err == nil {
+			decision.Model = b.model
+			a.savePortfolioAnalysis(decision.PortfolioAnalysis)
+			return decision, trace, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", b.model, err))
+		if last || ctx.Err() != nil {
+			break
+		}
+		log.Printf("   ... ⚠️ [降级] %s 失败: %v，改用 %s", b.model, err, a.backends[i+1].model)
 	}
+	return lo.Empty[entity.AgentDecision](), trace, errors.Join(errs...)
+}
 
-	completion, err := a.client.Chat.Completions.New(ctx, param)
-	if err != nil {
-		return lo.Empty[entity.AgentDecision](), fmt.Errorf("failed to get completion: %w", err)
+// savePortfolioAnalysis 更新最后的组合分析，下一次分析时会提供给模型
+func (a *Agent) savePortfolioAnalysis(analysis string) {
+	a.lastPortfolioAnalysis = analysis
+	if err := a.persistence.SavePortfolioAnalysis(analysis); err != nil {
+		log.Printf("warning: failed to save portfolio analysis: %v", err)
 	}
-	decision, err := utils.ParseResult[entity.AgentDecision](completion)
-	if err != nil {
-		return lo.Empty[entity.AgentDecision](), fmt.Errorf("failed to parse completion: %w", err)
+}
+
+// analyze 请求 b 给出决策，原始回答追加到 trace.Completions。
+// 启用工具调用时，回答中的工具调用会被执行并把结果追加到对话中，直到模型给出不含工具调用的回答；工具调用的轮次不计入修正次数。
+// 回答无法解析或有 action 违反约束时，会把上一次回答与违规列表追加到对话中要求模型修正，
+// 最多修正 model.max_repair_attempts 次，且不会超出 ctx 的截止时间。
+// 修正次数用尽后，解析失败返回错误，违反约束的 action 被逐个剔除。
+func (a *Agent) analyze(
+	ctx context.Context,
+	b backend,
+	data entity.PromptData,
+	trace *entity.Trace,
+) (entity.AgentDecision, entity.Usage, error) {
+	param := b.newParams(trace.SystemPrompt, trace.UserPrompt)
+	param.Tools = a.toolParams
+
+	var (
+		decision  entity.AgentDecision
+		usage     = entity.Usage{Model: b.model}
+		toolCalls int
+	)
+	for attempt := 0; ; {
+		start := time.Now()
+		completion, err := b.completions.New(ctx, param)
+		usage.Requests++
+		usage.Latency += time.Since(start)
+		if err != nil {
+			trace.Completions = append(trace.Completions, entity.Completion{Model: b.model, Error: err.Error()})
+			return lo.Empty[entity.AgentDecision](), usage, fmt.Errorf("failed to get completion: %w", err)
+		}
+		addUsage(&usage, completion.Usage)
+
+		if len(completion.Choices) > 0 && len(completion.Choices[0].Message.ToolCalls) > 0 {
+			trace.Completions = append(trace.Completions, newCompletion(b.model, completion, nil))
+			if toolCalls > a.maxToolCalls {
+				return lo.Empty[entity.AgentDecision](), usage, fmt.Errorf("model kept calling tools after the limit of %d", a.maxToolCalls)
+			}
+			message := completion.Choices[0].Message
+			param.Messages = append(param.Messages, message.ToParam())
+			param.Messages = append(param.Messages, a.callTools(ctx, b.model, message.ToolCalls, &toolCalls, trace)...)
+			if toolCalls >= a.maxToolCalls {
+				param.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("none")}
+			}
+			continue
+		}
+
+		var violations []string
+		var rejected []*ValidationError
+		decision, err = utils.ParseResult[entity.AgentDecision](completion)
+		if err != nil {
+			violations = []string{err.Error()}
+		} else {
+			decision.Actions, rejected = Validate(decision.Actions, data, a.rules)
+			violations = lo.Map(rejected, func(e *ValidationError, _ int) string { return e.Error() })
+		}
+		trace.Completions = append(trace.Completions, newCompletion(b.model, completion, violations))
+		if len(violations) == 0 {
+			return decision, usage, nil
+		}
+
+		// 下一次请求预计无法在截止时间前完成时不再尝试修正
+		deadline, hasDeadline := ctx.Deadline()
+		if attempt >= config.App.Model.MaxRepairAttempts || (hasDeadline && time.Until(deadline) < time.Since(start)) {
+			if err != nil {
+				return lo.Empty[entity.AgentDecision](), usage, fmt.Errorf("failed to parse completion: %w", err)
+			}
+			logRejected(rejected)
+			return decision, usage, nil
+		}
+
+		attempt++
+		// 供应商在内容过滤等情况下可能返回空的 choices，没有可以修正的回答，原样重新请求
+		if len(completion.Choices) == 0 {
+			log.Printf("   ... 🔁 [重试] %s 第 %d 次回答没有内容，重新请求", b.model, attempt)
+			continue
+		}
+		log.Printf("   ... 🔧 [修正] %s 第 %d 次回答有 %d 处问题，要求模型修正: %s", b.model, attempt, len(violations), strings.Join(violations, "; "))
+		param.Messages = append(param.Messages,
+			openai.AssistantMessage(completion.Choices[0].Message.Content),
+			openai.UserMessage(prompts.BuildRepairPrompt(violations)),
+		)
 	}
+}
 
-	// 更新最后的组合分析
-	a.lastPortfolioAnalysis = decision.PortfolioAnalysis
-	if err := config.SavePortfolioPersistence(a.lastPortfolioAnalysis); err != nil {
-		log.Printf("warning: failed to save portfolio analysis: %v", err)
+// addUsage 累加一次请求的用量。
+// 部分供应商 (e.g. Moonshot) 把命中缓存的 token 数放在 usage.cached_tokens 而不是 prompt_tokens_details 中。
+func addUsage(usage *entity.Usage, u openai.CompletionUsage) {
+	usage.PromptTokens += u.PromptTokens
+	usage.CompletionTokens += u.CompletionTokens
+	usage.ReasoningTokens += u.CompletionTokensDetails.ReasoningTokens
+	cached := u.PromptTokensDetails.CachedTokens
+	if field, ok := u.JSON.ExtraFields["cached_tokens"]; ok && cached == 0 {
+		cached, _ = strconv.ParseInt(field.Raw(), 10, 64)
 	}
+	usage.CachedTokens += cached
+}
 
-	return decision, nil
+// newCompletion 提取回答的原始内容。
+// 推理内容不在 OpenAI 的标准字段中，DeepSeek、Kimi、豆包等放在 reasoning_content，OpenRouter 等放在 reasoning。
+func newCompletion(model string, completion *openai.ChatCompletion, violations []string) entity.Completion {
+	c := entity.Completion{Model: model, Violations: violations}
+	if len(completion.Choices) == 0 {
+		return c
+	}
+	message := completion.Choices[0].Message
+	c.Content = message.Content
+	for _, key := range []string{"reasoning_content", "reasoning"} {
+		if field, ok := message.JSON.ExtraFields[key]; ok && json.UnmarshalString(field.Raw(), &c.Reasoning) == nil && c.Reasoning != "" {
+			break
+		}
+	}
+	return c
 }
@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+)
+
+// ErrCassetteMiss 表示回放模式下没有与本次请求对应的录制回答
+var ErrCassetteMiss = errors.New("cassette miss")
+
+// sampleKey 是 context 中同一模型对同一对话的采样序号，集成决策中同一模型出现多次时用于区分各次回答
+type sampleKey struct{}
+
+func withSample(ctx context.Context, sample int) context.Context {
+	return context.WithValue(ctx, sampleKey{}, sample)
+}
+
+// completer 发送一次 Chat Completion 请求，*openai.ChatCompletionService 满足该接口
+type completer interface {
+	New(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error)
+}
+
+// cassette 按模型与完整对话 (系统提示词、用户提示词以及修正轮次) 的哈希录制或回放回答。
+// 回答以供应商返回的原始 JSON 保存，回放时推理内容、用量等非标准字段也完全一致。
+type cassette struct {
+	next   completer
+	dir    string
+	replay bool
+}
+
+// newCassette 按 cfg.Mode 包装 next，Mode 为空时直接返回 next
+func newCassette(next completer, cfg config.CassetteConfig) completer {
+	switch cfg.Mode {
+	case "record":
+		log.Printf("... 🎞️ 录制模式: LLM 回答将保存到 %s", cfg.Dir)
+		return &cassette{next: next, dir: cfg.Dir}
+	case "replay":
+		log.Printf("... 🎞️ 回放模式: 只使用 %s 中录制的 LLM 回答", cfg.Dir)
+		return &cassette{next: next, dir: cfg.Dir, replay: true}
+	default:
+		return next
+	}
+}
+
+func (c *cassette) New(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error) {
+	sample, _ := ctx.Value(sampleKey{}).(int)
+	key, err := cassetteKey(body, sample)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(c.dir, key+".json")
+
+	if c.replay {
+		raw, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: no recorded completion for %s (key %s)", ErrCassetteMiss, body.Model, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		var completion openai.ChatCompletion
+		if err := completion.UnmarshalJSON(raw); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+		}
+		return &completion, nil
+	}
+
+	completion, err := c.next.New(ctx, body, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.save(path, completion.RawJSON()); err != nil {
+		log.Printf("⚠️ [录制] 无法保存 LLM 回答: %v", err)
+	}
+	return completion, nil
+}
+
+// save 先写入临时文件再重命名，避免中断时留下不完整的录制
+func (c *cassette) save(path, raw string) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(raw), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// cassetteKey 是模型名、全部消息与采样序号的 SHA-256，温度等采样参数不参与计算
+func cassetteKey(body openai.ChatCompletionNewParams, sample int) (string, error) {
+	data, err := json.Marshal(struct {
+		Model    string                                   `json:"model"`
+		Messages []openai.ChatCompletionMessageParamUnion `json:"messages"`
+		Sample   int                                      `json:"sample,omitempty"`
+	}{body.Model, body.Messages, sample})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,193 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+)
+
+// Analyst 根据行情给出交易决策，*Agent 与 *Ensemble 满足该接口
+type Analyst interface {
+	Prompts(data entity.PromptData) (system, user string)
+	RunAnalysis(ctx context.Context, data entity.PromptData) (entity.AgentDecision, entity.Trace, error)
+}
+
+// Ensemble 用同一份提示词并发询问多个成员，按币种投票汇总决策。
+// 同一模型可以出现多次，每次都是一次独立的采样。
+type Ensemble struct {
+	agent   *Agent   // 提供提示词、组合分析与持久化，backends 即全部成员
+	members []string // 成员名，与 agent.backends 一一对应
+	samples []int    // 同一模型的第几次采样，用于区分录制的回答
+	quorum  int
+}
+
+// NewEnsemble 创建由 models 组成的集成决策，quorum 为 0 时取成员数的过半数
+func NewEnsemble(exchange string, coins []string, models []string, quorum int, startingCapital float64, opts ...Option) (*Ensemble, error) {
+	agent, err := newAgent(exchange, coins, strings.Join(lo.Uniq(models), " + "), models, startingCapital, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if quorum == 0 {
+		quorum = len(models)/2 + 1
+	}
+
+	e := &Ensemble{agent: agent, quorum: quorum}
+	counts := make(map[string]int, len(models))
+	for _, model := range models {
+		e.samples = append(e.samples, counts[model])
+		counts[model]++
+		e.members = append(e.members, lo.Ternary(counts[model] > 1, fmt.Sprintf("%s#%d", model, counts[model]), model))
+	}
+	log.Printf("... 🗳️ 集成决策: %s，法定票数 %d/%d", strings.Join(e.members, ", "), quorum, len(models))
+	return e, nil
+}
+
+// Prompts 返回本次分析将提交给每个成员的系统提示词与用户提示词
+func (e *Ensemble) Prompts(data entity.PromptData) (system, user string) {
+	return e.agent.Prompts(data)
+}
+
+// RunAnalysis 并发询问所有成员并汇总决策，返回的决策 Model 为 ensemble。
+// 没有给出有效回答的成员不参与投票，全部成员都失败时返回错误。
+func (e *Ensemble) RunAnalysis(
+	ctx context.Context,
+	data entity.PromptData,
+) (entity.AgentDecision, entity.Trace, error) {
+	system, user := e.Prompts(data)
+	backends := e.agent.backends
+	traces := make([]entity.Trace, len(backends))
+	trace := entity.Trace{
+		SystemPrompt: system,
+		UserPrompt:   user,
+		Usage:        make([]entity.Usage, len(backends)),
+		Votes:        make([]entity.Vote, len(backends)),
+	}
+	errs := make([]error, len(backends))
+
+	var wg sync.WaitGroup
+	for i, b := range backends {
+		wg.Go(func() {
+			traces[i] = entity.Trace{SystemPrompt: system, UserPrompt: user}
+			decision, usage, err := e.agent.analyze(withSample(ctx, e.samples[i]), b, data, &traces[i])
+			trace.Usage[i] = usage
+			trace.Votes[i] = entity.Vote{Member: e.members[i], Decision: decision}
+			if err != nil {
+				trace.Votes[i].Error = err.Error()
+				errs[i] = fmt.Errorf("%s: %w", e.members[i], err)
+				log.Printf("   ... ⚠️ [投票] %s 没有给出有效回答: %v", e.members[i], err)
+			}
+		})
+	}
+	wg.Wait()
+	for _, t := range traces {
+		trace.Completions = append(trace.Completions, t.Completions...)
+		trace.ToolCalls = append(trace.ToolCalls, t.ToolCalls...)
+	}
+
+	valid := lo.Filter(trace.Votes, func(v entity.Vote, _ int) bool { return v.Error == "" })
+	if len(valid) == 0 {
+		return lo.Empty[entity.AgentDecision](), trace, fmt.Errorf("no ensemble member returned a valid decision: %w", errors.Join(errs...))
+	}
+
+	decision := entity.AgentDecision{
+		PortfolioAnalysis: strings.Join(lo.Map(valid, func(v entity.Vote, _ int) string {
+			return fmt.Sprintf("[%s] %s", v.Member, v.Decision.PortfolioAnalysis)
+		}), "\n\n"),
+		Actions: aggregate(valid, e.quorum),
+		Model:   "ensemble",
+	}
+	e.agent.savePortfolioAnalysis(decision.PortfolioAnalysis)
+	return decision, trace, nil
+}
+
+// ballot 是一个成员在一个币种上的一票
+type ballot struct {
+	member string
+	action entity.TradeSignal
+}
+
+// aggregate 按币种汇总 votes 中的 action。
+// 每个成员在一个币种上只计第一个 action，没有 action 视为持有；票数最多且达到 quorum 的动作胜出，平票时不操作。
+// 胜出的一方中止盈止损、数量与风险金额取中位数，杠杆取最小值，信心取平均，失效条件与理由取信心最高的一票。
+func aggregate(votes []entity.Vote, quorum int) []entity.TradeSignal {
+	var coins []string
+	byCoin := make(map[string][]ballot)
+	for _, v := range votes {
+		for _, action := range lo.UniqBy(v.Decision.Actions, func(a entity.TradeSignal) string { return a.Coin }) {
+			if _, ok := byCoin[action.Coin]; !ok {
+				coins = append(coins, action.Coin)
+			}
+			byCoin[action.Coin] = append(byCoin[action.Coin], ballot{member: v.Member, action: action})
+		}
+	}
+
+	var result []entity.TradeSignal
+	for _, coin := range coins {
+		bySignal := lo.GroupBy(byCoin[coin], func(b ballot) string { return b.action.Signal })
+		signals := lo.Keys(bySignal)
+		slices.Sort(signals)
+		slices.SortStableFunc(signals, func(a, b string) int { return len(bySignal[b]) - len(bySignal[a]) })
+
+		tally := lo.Map(signals, func(signal string, _ int) string {
+			return fmt.Sprintf("%s ×%d (%s)", signal, len(bySignal[signal]),
+				strings.Join(lo.Map(bySignal[signal], func(b ballot, _ int) string { return b.member }), ", "))
+		})
+		if holds := len(votes) - len(byCoin[coin]); holds > 0 {
+			tally = append(tally, fmt.Sprintf("持有 ×%d", holds))
+		}
+
+		winner := bySignal[signals[0]]
+		switch {
+		case len(signals) > 1 && len(bySignal[signals[1]]) == len(winner):
+			log.Printf("   ... 🗳️ [投票] %s: %s → 平票，不操作", coin, strings.Join(tally, ", "))
+		case len(winner) < quorum:
+			log.Printf("   ... 🗳️ [投票] %s: %s → 未达法定票数 %d，不操作", coin, strings.Join(tally, ", "), quorum)
+		default:
+			log.Printf("   ... 🗳️ [投票] %s: %s → %s", coin, strings.Join(tally, ", "), signals[0])
+			result = append(result, merge(winner, len(votes)))
+		}
+	}
+	return result
+}
+
+// merge 合并同一币种上同一动作的多张选票，total 为有效投票的成员数
+func merge(ballots []ballot, total int) entity.TradeSignal {
+	actions := lo.Map(ballots, func(b ballot, _ int) entity.TradeSignal { return b.action })
+	field := func(get func(entity.TradeSignal) float64) []float64 {
+		return lo.Map(actions, func(a entity.TradeSignal, _ int) float64 { return get(a) })
+	}
+	best := lo.MaxBy(actions, func(a, b entity.TradeSignal) bool { return a.Confidence > b.Confidence })
+
+	return entity.TradeSignal{
+		Signal:                best.Signal,
+		Coin:                  best.Coin,
+		Quantity:              median(field(func(a entity.TradeSignal) float64 { return a.Quantity })),
+		Leverage:              lo.Min(lo.Map(actions, func(a entity.TradeSignal, _ int) int { return a.Leverage })),
+		ProfitTarget:          median(field(func(a entity.TradeSignal) float64 { return a.ProfitTarget })),
+		StopLoss:              median(field(func(a entity.TradeSignal) float64 { return a.StopLoss })),
+		InvalidationCondition: best.InvalidationCondition,
+		Confidence:            utils.Avg(field(func(a entity.TradeSignal) float64 { return a.Confidence })),
+		RiskUSD:               median(field(func(a entity.TradeSignal) float64 { return a.RiskUSD })),
+		Justification:         fmt.Sprintf("ensemble %d/%d: %s", len(ballots), total, best.Justification),
+	}
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
@@ -1,27 +1,139 @@
 package llm
 
 import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/gtoxlili/echoAlpha/config"
 	"github.com/openai/openai-go/v2"
 	"github.com/openai/openai-go/v2/option"
+	"github.com/openai/openai-go/v2/shared"
+	"github.com/samber/lo"
 )
 
-func resolveClient(modelName string) (openai.Client, error) {
-
-	switch {
-	case strings.HasPrefix(modelName, "doubao-"):
-		return openai.NewClient(
-			option.WithAPIKey(config.VOLC_API_KEY),
-			option.WithBaseURL(config.VOLC_BASE_URL),
-		), nil
-	case strings.HasPrefix(modelName, "kimi-"):
-		return openai.NewClient(
-			option.WithAPIKey(config.KIMI_API_KEY),
-			option.WithBaseURL(config.KIMI_BASE_URL),
-		), nil
+// builtinProviders 是内置的供应商，密钥与地址编译在 config 包中
+var builtinProviders = []config.ProviderConfig{
+	{
+		Name:            "doubao",
+		Prefixes:        []string{"doubao-"},
+		BaseURL:         config.VOLC_BASE_URL,
+		APIKey:          config.VOLC_API_KEY,
+		JSONMode:        "json_schema",
+		ReasoningEffort: "high",
+		MaxTokens:       1024 * 32,
+	},
+	{
+		Name:            "kimi",
+		Prefixes:        []string{"kimi-"},
+		BaseURL:         config.KIMI_BASE_URL,
+		APIKey:          config.KIMI_API_KEY,
+		JSONMode:        "json_object",
+		ReasoningEffort: "high",
+		MaxTokens:       1024 * 32,
+	},
+}
+
+// customEndpoint 是 model.base_url 显式指定接入点、但没有匹配的供应商时使用的参数
+var customEndpoint = config.ProviderConfig{
+	Name:            "custom",
+	JSONMode:        "json_object",
+	ReasoningEffort: "high",
+	MaxTokens:       1024 * 32,
+}
+
+// resolveProvider 返回 modelName 使用的供应商。
+// model.provider 显式指定时按名称查找，否则按最长的模型名前缀匹配 (前缀相同时配置文件中的供应商优先)；
+// 配置文件中的供应商与内置供应商同名时覆盖内置配置。
+// 配置文件中为 modelName 显式指定的 model.api_key / model.base_url 各自优先于供应商的密钥与接入点，
+// 只指定 base_url 而没有匹配的供应商时使用 customEndpoint。
+func resolveProvider(modelName string) (config.ProviderConfig, error) {
+	registry := append(slices.Clone(config.App.Providers), lo.Filter(builtinProviders, func(builtin config.ProviderConfig, _ int) bool {
+		return !lo.ContainsBy(config.App.Providers, func(p config.ProviderConfig) bool { return p.Name == builtin.Name })
+	})...)
+
+	model := config.App.Model
+	explicit := model.Name == modelName
+	var (
+		provider config.ProviderConfig
+		found    bool
+	)
+	if explicit && model.Provider != "" {
+		provider, found = lo.Find(registry, func(p config.ProviderConfig) bool { return p.Name == model.Provider })
+		if !found {
+			return provider, fmt.Errorf("unknown provider %q for model %s", model.Provider, modelName)
+		}
+	} else {
+		matched := 0
+		for _, p := range registry {
+			for _, prefix := range p.Prefixes {
+				if strings.HasPrefix(modelName, prefix) && len(prefix) > matched {
+					provider, found, matched = p, true, len(prefix)
+				}
+			}
+		}
+	}
+
+	// model.base_url 与 model.api_key 分别覆盖，只指定 base_url 时 (e.g. 无需密钥的本地 vLLM) 也生效
+	if explicit && model.BaseURL != "" {
+		if !found {
+			provider = customEndpoint
+		}
+		provider.BaseURL = model.BaseURL
+		found = true
+	}
+	if explicit && model.APIKey != "" {
+		provider.APIKey, provider.APIKeyEnv = model.APIKey, ""
+	}
+	if !found {
+		return provider, fmt.Errorf("no provider configured for model %s (add one under providers or set model.provider)", modelName)
+	}
+	return provider, nil
+}
+
+func resolveClient(provider config.ProviderConfig) (openai.Client, error) {
+	apiKey := provider.APIKey
+	if apiKey == "" && provider.APIKeyEnv != "" {
+		apiKey = os.Getenv(provider.APIKeyEnv)
+		if apiKey == "" {
+			return openai.Client{}, fmt.Errorf("environment variable %s for provider %s is not set", provider.APIKeyEnv, provider.Name)
+		}
+	}
+
+	opts := []option.RequestOption{option.WithBaseURL(provider.BaseURL)}
+	if apiKey != "" {
+		opts = append(opts, option.WithAPIKey(apiKey))
+	}
+	return openai.NewClient(opts...), nil
+}
+
+// resolveResponseFormat 按供应商的 json_mode 返回 response_format，json_schema 时使用名为 schemaName 的 schema。
+// 配置文件中的 model.response_format 优先于供应商的设置，为 none 时返回零值 (不发送 response_format)。
+func resolveResponseFormat(modelName string, provider config.ProviderConfig, schemaName string, schema map[string]any) openai.ChatCompletionNewParamsResponseFormatUnion {
+	format := lo.CoalesceOrEmpty(provider.JSONMode, "json_object")
+	if model := config.App.Model; model.Name == modelName && model.ResponseFormat != "" {
+		format = model.ResponseFormat
+	}
+
+	switch format {
+	case "none":
+		return openai.ChatCompletionNewParamsResponseFormatUnion{}
+	case "json_schema":
+		log.Printf("... %s 使用 Structured Outputs (JSON Schema)", modelName)
+		return openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   schemaName,
+					Strict: openai.Bool(true),
+					Schema: schema,
+				},
+			},
+		}
 	default:
-		panic("unimplemented model provider resolver")
+		return openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: lo.ToPtr(shared.NewResponseFormatJSONObjectParam()),
+		}
 	}
 }
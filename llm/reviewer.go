@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/prompts"
+	"github.com/gtoxlili/echoAlpha/utils"
+
+	"github.com/samber/lo"
+)
+
+// Reviewer 在下单前用另一个模型 (可以是更便宜的模型) 复核交易模型给出的决策，
+// 逐个 action 给出通过、否决或收紧的裁决。
+type Reviewer struct {
+	backend      backend
+	systemPrompt string
+	minLeverage  int
+}
+
+// NewReviewer 创建使用 model 的复核模型
+func NewReviewer(exchange string, coins []string, model string) (*Reviewer, error) {
+	b, err := newBackend(model, "trade_review", reviewSchema(coins))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("... 🧑‍⚖️ 复核模型: %s", model)
+	return &Reviewer{
+		backend:      b,
+		systemPrompt: prompts.BuildReviewSystemPrompt(exchange, coins, config.App.Trading.MinLeverage),
+		minLeverage:  config.App.Trading.MinLeverage,
+	}, nil
+}
+
+// Model 返回复核模型的名称
+func (r *Reviewer) Model() string {
+	return r.backend.model
+}
+
+// Review 把 data 与 decision 交给复核模型，返回通过 (含收紧后) 的 action 与复核模型的全部裁决。
+// 没有得到裁决的 action 视为否决；请求或解析失败时返回错误，此时不应执行任何 action。
+// 无论成功与否都会返回提示词、原始回答与用量。
+func (r *Reviewer) Review(
+	ctx context.Context,
+	data entity.PromptData,
+	decision entity.AgentDecision,
+) ([]entity.TradeSignal, []entity.Review, entity.Trace, error) {
+	trace := entity.Trace{
+		SystemPrompt: r.systemPrompt,
+		UserPrompt:   prompts.BuildReviewUserPrompt(data, decision),
+	}
+	usage := entity.Usage{Model: r.backend.model, Requests: 1}
+	start := time.Now()
+	completion, err := r.backend.completions.New(ctx, r.backend.newParams(trace.SystemPrompt, trace.UserPrompt))
+	usage.Latency = time.Since(start)
+	if err != nil {
+		trace.Usage = []entity.Usage{usage}
+		trace.Completions = append(trace.Completions, entity.Completion{Model: r.backend.model, Error: err.Error()})
+		return nil, nil, trace, fmt.Errorf("failed to get review: %w", err)
+	}
+	addUsage(&usage, completion.Usage)
+	trace.Usage = []entity.Usage{usage}
+
+	result, err := utils.ParseResult[entity.ReviewResult](completion)
+	var violations []string
+	if err != nil {
+		violations = []string{err.Error()}
+	}
+	trace.Completions = append(trace.Completions, newCompletion(r.backend.model, completion, violations))
+	if err != nil {
+		return nil, nil, trace, fmt.Errorf("failed to parse review: %w", err)
+	}
+
+	reviews := lo.UniqBy(result.Reviews, func(v entity.Review) string { return strings.ToUpper(v.Coin) })
+	byCoin := lo.KeyBy(reviews, func(v entity.Review) string { return strings.ToUpper(v.Coin) })
+	var approved []entity.TradeSignal
+	for _, action := range decision.Actions {
+		review, ok := byCoin[strings.ToUpper(action.Coin)]
+		if !ok {
+			log.Printf("   ... 🧑‍⚖️ [复核] %s %s: 没有得到裁决，视为否决", action.Signal, action.Coin)
+			continue
+		}
+		switch review.Verdict {
+		case entity.VerdictApprove:
+			log.Printf("   ... 🧑‍⚖️ [复核] %s %s: 通过。%s", action.Signal, action.Coin, review.Reason)
+			approved = append(approved, action)
+		case entity.VerdictTighten:
+			tightened, changes := r.tighten(action, review, data.Coins[action.Coin].Price)
+			log.Printf("   ... 🧑‍⚖️ [复核] %s %s: 收紧 (%s)。%s", action.Signal, action.Coin,
+				lo.Ternary(len(changes) == 0, "无有效调整", strings.Join(changes, ", ")), review.Reason)
+			approved = append(approved, tightened)
+		default:
+			log.Printf("   ... 🧑‍⚖️ [复核] %s %s: 否决。%s", action.Signal, action.Coin, review.Reason)
+		}
+	}
+	return approved, result.Reviews, trace, nil
+}
+
+// tighten 按 review 收紧开仓 action，只接受更保守的数值，返回调整后的 action 与生效的调整。
+// RiskUSD 随后由风控按实际止损距离重新计算；平仓 action 原样返回。
+func (r *Reviewer) tighten(action entity.TradeSignal, review entity.Review, price float64) (entity.TradeSignal, []string) {
+	var changes []string
+	long := action.Signal == "buy_to_enter"
+	if !long && action.Signal != "sell_to_enter" {
+		return action, changes
+	}
+
+	if review.Quantity > 0 && review.Quantity < action.Quantity {
+		changes = append(changes, fmt.Sprintf("数量 %g → %g", action.Quantity, review.Quantity))
+		action.Quantity = review.Quantity
+	}
+	if review.Leverage >= r.minLeverage && review.Leverage < action.Leverage {
+		changes = append(changes, fmt.Sprintf("杠杆 %dx → %dx", action.Leverage, review.Leverage))
+		action.Leverage = review.Leverage
+	}
+	// 止损只能向当前价格靠近且仍在保护方向上
+	if sl := review.StopLoss; price > 0 && sl > 0 &&
+		lo.Ternary(long, sl > action.StopLoss && sl < price, sl < action.StopLoss && sl > price) {
+		changes = append(changes, fmt.Sprintf("止损 %g → %g", action.StopLoss, sl))
+		action.StopLoss = sl
+	}
+	// 止盈同样只能向当前价格靠近 (更早兑现)，更远的止盈会放大持仓时间与风险
+	if tp := review.ProfitTarget; price > 0 && tp > 0 &&
+		lo.Ternary(long, tp < action.ProfitTarget && tp > price, tp > action.ProfitTarget && tp < price) {
+		changes = append(changes, fmt.Sprintf("止盈 %g → %g", action.ProfitTarget, tp))
+		action.ProfitTarget = tp
+	}
+	return action, changes
+}
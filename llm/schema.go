@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+)
+
+// decisionSchema 从 entity.AgentDecision 的结构生成 JSON Schema，用于 Structured Outputs。
+// signal 与 coin 字段额外限定为允许的取值。按 strict 模式的要求，所有字段都是必填的，且不允许额外字段。
+func decisionSchema(coins []string) map[string]any {
+	return schemaOf(reflect.TypeFor[entity.AgentDecision](), map[string][]string{
+		"signal": {"buy_to_enter", "sell_to_enter", "close"},
+		"coin":   coins,
+	})
+}
+
+// reviewSchema 是复核模型回答的 JSON Schema
+func reviewSchema(coins []string) map[string]any {
+	return schemaOf(reflect.TypeFor[entity.ReviewResult](), map[string][]string{
+		"verdict": {entity.VerdictApprove, entity.VerdictReject, entity.VerdictTighten},
+		"coin":    coins,
+	})
+}
+
+// schemaOf 按 json 标签递归地描述 t，enums 以 json 字段名为 key 限定字符串字段的取值
+func schemaOf(t reflect.Type, enums map[string][]string) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), enums)
+	case reflect.Struct:
+		properties := make(map[string]any, t.NumField())
+		required := make([]string, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			property := schemaOf(field.Type, enums)
+			if values, ok := enums[name]; ok && field.Type.Kind() == reflect.String {
+				property["enum"] = values
+			}
+			properties[name] = property
+			required = append(required, name)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), enums)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
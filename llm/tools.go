@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/prompts"
+
+	json "github.com/bytedance/sonic"
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/shared"
+	"github.com/samber/lo"
+)
+
+// Tool 是模型在分析时可以调用的一个函数
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // 参数的 JSON Schema
+	// Call 执行调用，arguments 为模型给出的原始 JSON，返回值会序列化为 JSON 交给模型
+	Call func(ctx context.Context, arguments string) (any, error)
+}
+
+// WithTools 启用工具调用模式: 模型可以先调用 tools 获取更多数据，再给出最终决策。
+// 每个模型在一次分析中最多调用 maxCalls 次，用尽后拒绝后续调用并要求模型直接给出决策。
+func WithTools(tools []Tool, maxCalls int) Option {
+	return func(a *Agent) {
+		if len(tools) == 0 {
+			return
+		}
+		a.tools = lo.SliceToMap(tools, func(t Tool) (string, Tool) { return t.Name, t })
+		a.toolParams = lo.Map(tools, func(t Tool, _ int) openai.ChatCompletionToolUnionParam {
+			return openai.ChatCompletionFunctionTool(shared.FunctionDefinitionParam{
+				Name:        t.Name,
+				Description: openai.String(t.Description),
+				Parameters:  t.Parameters,
+			})
+		})
+		a.maxToolCalls = maxCalls
+		a.systemPrompt = prompts.BuildToolsPrompt(a.systemPrompt, maxCalls)
+		log.Printf("... 🧰 工具调用: %d 个工具，每个决策周期最多调用 %d 次", len(tools), maxCalls)
+	}
+}
+
+// callTools 依次执行一轮回答中的全部工具调用，返回要追加到对话中的工具消息。
+// 每次调用都记录到 trace.ToolCalls；used 为本次分析中已调用的次数，超出 a.maxToolCalls 的调用不会执行。
+func (a *Agent) callTools(
+	ctx context.Context,
+	model string,
+	calls []openai.ChatCompletionMessageToolCallUnion,
+	used *int,
+	trace *entity.Trace,
+) []openai.ChatCompletionMessageParamUnion {
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(calls))
+	for _, call := range calls {
+		record := entity.ToolCall{Model: model, Name: call.Function.Name, Arguments: call.Function.Arguments}
+		start := time.Now()
+		result, err := a.callTool(ctx, call, *used)
+		record.Latency = time.Since(start)
+		*used++
+
+		if err == nil {
+			record.Result, err = json.MarshalString(result)
+		}
+		if err != nil {
+			record.Error = err.Error()
+			record.Result, _ = json.MarshalString(map[string]string{"error": record.Error})
+			log.Printf("   ... ⚠️ [工具] %s 调用 %s(%s) 失败: %v", model, record.Name, record.Arguments, err)
+		} else {
+			log.Printf("   ... 🧰 [工具] %s 调用 %s(%s)，耗时 %s", model, record.Name, record.Arguments, record.Latency.Round(time.Millisecond))
+		}
+		trace.ToolCalls = append(trace.ToolCalls, record)
+		messages = append(messages, openai.ToolMessage(record.Result, call.ID))
+	}
+	return messages
+}
+
+func (a *Agent) callTool(ctx context.Context, call openai.ChatCompletionMessageToolCallUnion, used int) (any, error) {
+	if used >= a.maxToolCalls {
+		return nil, fmt.Errorf("tool call limit (%d) reached, reply with the final decision now", a.maxToolCalls)
+	}
+	tool, ok := a.tools[call.Function.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	return tool.Call(ctx, call.Function.Arguments)
+}
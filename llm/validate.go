@@ -0,0 +1,124 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/invalidation"
+)
+
+// 校验失败的类别，可以用 errors.Is 判断 ValidationError 属于哪一类
+var (
+	ErrInvalidSignal      = errors.New("invalid signal")
+	ErrUnknownCoin        = errors.New("coin not in universe")
+	ErrInvalidQuantity    = errors.New("invalid quantity")
+	ErrLeverageOutOfRange = errors.New("leverage out of range")
+	ErrExitPlanSide       = errors.New("stop loss / profit target on wrong side")
+	ErrNotionalTooSmall   = errors.New("notional below minimum")
+	ErrInvalidConfidence  = errors.New("confidence out of range")
+	ErrNoPosition         = errors.New("no open position to close")
+	ErrInvalidCondition   = errors.New("invalid invalidation condition")
+)
+
+// ValidationError 是单个 action 未通过语义校验的原因
+type ValidationError struct {
+	Index  int // action 在 AgentDecision.Actions 中的下标
+	Action entity.TradeSignal
+	Field  string // 出错的 JSON 字段
+	Err    error  // 上面定义的类别之一
+	Detail string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("actions[%d] (%s %s): %s: %v: %s", e.Index, e.Action.Signal, e.Action.Coin, e.Field, e.Err, e.Detail)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationRules 是 AgentDecision 的语义约束，与系统提示词中对模型的要求一致
+type ValidationRules struct {
+	Coins       []string
+	MinLeverage int
+	MaxLeverage int
+	MinNotional float64 // 交易所最小名义价值 (USDT)
+}
+
+// Validate 逐个校验 actions，返回通过校验的 action 与每个被拒绝 action 的错误。
+// 开仓信号的价格相关检查以 data 中的当前价格为准，平仓信号要求 data 中存在对应持仓。
+func Validate(actions []entity.TradeSignal, data entity.PromptData, rules ValidationRules) ([]entity.TradeSignal, []*ValidationError) {
+	valid := make([]entity.TradeSignal, 0, len(actions))
+	var rejected []*ValidationError
+	for i, action := range actions {
+		if err := validateAction(action, data, rules); err != nil {
+			err.Index = i
+			rejected = append(rejected, err)
+			continue
+		}
+		valid = append(valid, action)
+	}
+	return valid, rejected
+}
+
+func validateAction(action entity.TradeSignal, data entity.PromptData, rules ValidationRules) *ValidationError {
+	reject := func(field string, err error, format string, args ...any) *ValidationError {
+		return &ValidationError{Action: action, Field: field, Err: err, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	if !slices.Contains(rules.Coins, action.Coin) {
+		return reject("coin", ErrUnknownCoin, "%q is not one of %v", action.Coin, rules.Coins)
+	}
+
+	switch action.Signal {
+	case "close":
+		if !slices.ContainsFunc(data.Positions, func(p entity.PositionData) bool { return p.Symbol == action.Coin }) {
+			return reject("coin", ErrNoPosition, "there is no open %s position", action.Coin)
+		}
+		return nil
+	case "buy_to_enter", "sell_to_enter":
+	default:
+		return reject("signal", ErrInvalidSignal, "%q must be one of buy_to_enter, sell_to_enter, close", action.Signal)
+	}
+
+	if action.Confidence < 0 || action.Confidence > 1 {
+		return reject("confidence", ErrInvalidConfidence, "%g must be within [0, 1]", action.Confidence)
+	}
+	if action.Quantity <= 0 {
+		return reject("quantity", ErrInvalidQuantity, "%g must be positive", action.Quantity)
+	}
+	if action.Leverage < rules.MinLeverage || action.Leverage > rules.MaxLeverage {
+		return reject("leverage", ErrLeverageOutOfRange, "%d must be within [%d, %d]", action.Leverage, rules.MinLeverage, rules.MaxLeverage)
+	}
+	// 失效条件由后台监控执行，必须能够被解析
+	if action.InvalidationCondition != "" {
+		if _, err := invalidation.Parse(action.InvalidationCondition); err != nil {
+			return reject("invalidation_condition", ErrInvalidCondition, "%q: %v", action.InvalidationCondition, err)
+		}
+	}
+
+	price := data.Coins[action.Coin].Price
+	if price <= 0 {
+		return nil // 没有当前价格时无法检查价格关系，交给风控否决
+	}
+	if action.Signal == "buy_to_enter" && !(action.StopLoss < price && price < action.ProfitTarget) {
+		return reject("stop_loss", ErrExitPlanSide, "long requires stop_loss < price < profit_target, got %g < %g < %g", action.StopLoss, price, action.ProfitTarget)
+	}
+	if action.Signal == "sell_to_enter" && !(action.ProfitTarget < price && price < action.StopLoss) {
+		return reject("stop_loss", ErrExitPlanSide, "short requires profit_target < price < stop_loss, got %g < %g < %g", action.ProfitTarget, price, action.StopLoss)
+	}
+	if notional := action.Quantity * price; notional < rules.MinNotional {
+		return reject("quantity", ErrNotionalTooSmall, "notional %.2f USDT (quantity %g × price %g) is below %.2f USDT", notional, action.Quantity, price, rules.MinNotional)
+	}
+	return nil
+}
+
+// logRejected 输出被拒绝的 action
+func logRejected(rejected []*ValidationError) {
+	for _, err := range rejected {
+		log.Printf("   ... 🚫 [校验] 拒绝 %s %s: %v", err.Action.Signal, err.Action.Coin, err)
+	}
+}
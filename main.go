@@ -2,115 +2,377 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gtoxlili/echoAlpha/collector"
 	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/cost"
+	"github.com/gtoxlili/echoAlpha/decisionlog"
 	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/gtoxlili/echoAlpha/invalidation"
+	"github.com/gtoxlili/echoAlpha/journal"
 	"github.com/gtoxlili/echoAlpha/llm"
+	"github.com/gtoxlili/echoAlpha/prompts"
+	"github.com/gtoxlili/echoAlpha/risk"
 	"github.com/gtoxlili/echoAlpha/trade"
+	"github.com/gtoxlili/echoAlpha/trigger"
+	"github.com/gtoxlili/echoAlpha/utils"
 	"github.com/samber/lo"
 )
 
+var (
+	configPath = flag.String("config", os.Getenv("ECHO_ALPHA_CONFIG"), "YAML 配置文件路径，为空时使用内置默认值 (环境变量 ECHO_ALPHA_* 总是会覆盖配置)")
+	paperMode  = flag.Bool("paper", false, "模拟盘模式: 使用实时行情，但在本地模拟账户中撮合")
+	shadowMode = flag.Bool("shadow", false, "影子模式: 按实盘规则构建订单但不发送，订单写入审计日志并在虚拟账户中撮合")
+)
+
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	flag.Usage = usage
+	flag.Parse()
 
-	log.Println("🤖 交易机器人启动...")
-	provider := collector.ResolveCollector("Binance", config.AssetUniverse)
-	startingCapital := provider.GetStartingCapital()
+	name, args := "run", flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := lo.Find(commands, func(c command) bool { return c.name == name })
+	if !ok {
+		fmt.Fprintf(flag.CommandLine.Output(), "未知的子命令: %s\n\n", name)
+		flag.Usage()
+		os.Exit(2)
+	}
 
-	agent, err := llm.NewAgent("Binance", config.AssetUniverse, "kimi-k2-thinking-turbo", startingCapital)
+	if *paperMode && *shadowMode {
+		fmt.Fprintln(flag.CommandLine.Output(), "-paper 与 -shadow 不能同时使用")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		log.Panicf("❌ [初始化] 致命错误: 无法创建 AI Agent: %v", err)
+		log.Panicf("❌ [配置] 致命错误: %v", err)
+	}
+	config.App = cfg
+	if err := prompts.LoadTemplates(cfg.Prompts.SystemTemplate, cfg.Prompts.UserTemplate); err != nil {
+		log.Panicf("❌ [配置] 致命错误: 无法加载提示词模板: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := cmd.run(ctx, args); err != nil {
+		log.Panicf("❌ [%s] 致命错误: %v", name, err)
 	}
+}
+
+// runLoop 按 K 线周期循环执行决策
+func runLoop(ctx context.Context, args []string) error {
+	flag.NewFlagSet("run", flag.ExitOnError).Parse(args)
 
-	tradeManager := trade.NewManager()
-	tradeExecutor, err := trade.NewExecutor(config.BINANCE_API_KEY, config.BINANCE_API_SECRET)
+	log.Println("🤖 交易机器人启动...")
+	t, err := newTrader(config.App, false)
 	if err != nil {
-		log.Panicf("❌ [初始化] 致命错误: 无法创建 Trade Executor: %v", err)
+		return err
+	}
+	if err := t.loadAgent(config.App.Model.Name); err != nil {
+		return err
 	}
 
-	log.Printf("... 交易所: Binance, 模型: %s", "kimi-k2-thinking-turbo")
-	log.Printf("... 初始资本: $%.2f", startingCapital)
-	log.Printf("... 决策周期: %.0f 分钟", config.KlineInterval.Minutes())
+	log.Printf("... 交易所: %s, 模型: %s", config.App.Exchange.Name, config.App.Model.Name)
+	log.Printf("... 初始资本: $%.2f", t.provider.GetStartingCapital())
+	log.Printf("... 决策周期: %.0f 分钟", config.App.Market.KlineInterval.Minutes())
 
 	now := time.Now()
-	nextTickTime := now.Truncate(config.KlineInterval).Add(config.KlineInterval)
+	nextTickTime := nextCycleTime()
 	durationToWait := time.Until(nextTickTime)
 	log.Printf("... 当前时间: %s", now.Format("2006-01-02 15:04:05"))
 	log.Printf("... K线对齐: 等待 %v, 将在 %s 执行首次分析...", durationToWait.Round(time.Second), nextTickTime.Format("15:04:05"))
 
-	// 启动主循环
+	if streamer, ok := t.market.(collector.Streamer); ok {
+		streamer.Stream(ctx)
+	}
+	if config.App.Monitor.Enabled && t.market != nil {
+		go invalidation.NewMonitor(config.App.Monitor.Interval, t.market, t.manager, t.closeInvalidated).Run(ctx)
+	}
+	var triggered <-chan []trigger.Event // 未启用事件触发时为 nil，select 永远不会选中
+	if tc := config.App.Triggers; tc.Enabled && t.market != nil {
+		t.triggers = trigger.NewWatcher(tc.Interval, tc.Cooldown, trigger.Rules{
+			PriceMoveATR:            tc.PriceMoveATR,
+			ExitProximityPct:        tc.ExitProximityPct,
+			LiquidationProximityPct: tc.LiquidationProximityPct,
+			FundingSpike:            tc.FundingSpike,
+			OISurgePct:              tc.OISurgePct,
+		}, t.market)
+		triggered = t.triggers.Events()
+		go t.triggers.Run(ctx)
+	}
+
+	// 启动主循环: 定时与事件触发的决策周期都在这里依次执行，同一时间只会有一个决策周期
+	timer := time.NewTimer(durationToWait)
+	defer timer.Stop()
 	for {
-		if err := delay(ctx); err != nil {
-			log.Printf("❌ 主循环延迟错误: %v", err)
-			return
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		case events := <-triggered:
+			reasons := strings.Join(lo.Map(events, func(e trigger.Event, _ int) string { return e.String() }), "; ")
+			if wait := time.Until(nextCycleTime()); wait < config.App.Triggers.Cooldown {
+				log.Printf("⚡ [事件触发] %s (%v 后即为定时决策，不提前)", reasons, wait.Round(time.Second))
+				continue
+			}
+			log.Printf("⚡ [事件触发] %s，提前开始决策周期", reasons)
+		}
+		t.runDecisionCycle(ctx)
+		timer.Reset(time.Until(nextCycleTime()))
+	}
+}
+
+// trader 汇集了一次决策周期所需的全部组件，实盘、模拟盘与回测共用
+type trader struct {
+	provider  collector.StateProvider
+	market    collector.MarketQuerier // 工具调用按需查询行情的数据源，为 nil 时不提供行情工具
+	agent     llm.Analyst
+	reviewer  *llm.Reviewer    // 下单前的复核模型，为 nil 时不复核
+	triggers  *trigger.Watcher // 提前开始决策周期的事件检查，为 nil 时只按 K 线周期决策
+	store     *config.Persistence
+	manager   *trade.Manager
+	executor  trade.Executor
+	risk      *risk.Engine
+	breaker   *risk.CircuitBreaker
+	journal   *journal.Journal
+	ledger    *cost.Ledger
+	decisions *decisionlog.Log
+	// dryRun 为 true 时只输出决策，不发送订单
+	dryRun bool
+	// mu 串行化决策周期与失效条件监控对持仓的修改
+	mu sync.Mutex
+}
+
+// modePaths 是一种运行模式 (实盘、-paper 或 -shadow) 的本地状态存储位置
+type modePaths struct {
+	Store, Journal, Usage, DecisionLog string
+}
+
+// statePaths 返回当前模式的持久化文件、交易日志、费用账本与决策审计日志的路径
+func statePaths(cfg *config.Config) modePaths {
+	switch {
+	case *paperMode:
+		return modePaths{cfg.Paper.PersistencePath, cfg.Paper.JournalPath, cfg.Paper.UsagePath, cfg.Paper.DecisionLogDir}
+	case *shadowMode:
+		return modePaths{cfg.Shadow.PersistencePath, cfg.Shadow.JournalPath, cfg.Shadow.UsagePath, cfg.Shadow.DecisionLogDir}
+	default:
+		return modePaths{cfg.Storage.PersistencePath, cfg.Storage.JournalPath, cfg.Storage.UsagePath, cfg.Storage.DecisionLogDir}
+	}
+}
+
+// newTrader 按配置组装实盘、模拟盘 (-paper) 或影子模式 (-shadow) 的 trader，AI Agent 需要另外调用 loadAgent 创建。
+// dryRun 为 true 时持久化状态、交易日志与模拟账户只读取不写回，订单也不会发送；LLM 费用仍然写入费用账本。
+func newTrader(cfg *config.Config, dryRun bool) (*trader, error) {
+	paths := statePaths(cfg)
+	creds := exchange.Credentials{
+		APIKey:    cfg.Exchange.APIKey,
+		APISecret: cfg.Exchange.APISecret,
+		BaseURL:   cfg.Exchange.BaseURL,
+	}
+
+	t := &trader{
+		risk:   newRiskEngine(),
+		dryRun: dryRun,
+	}
+	paperConfig := newPaperConfig(cfg)
+	paperConfig.PersistencePath, paperConfig.ReadOnly = cfg.Paper.AccountPath, dryRun
+	var market collector.StateProvider
+	switch {
+	case *paperMode:
+		log.Println("... 🧪 模拟盘模式: 订单只在本地模拟账户中撮合")
+		market = collector.ResolveMarketCollector(cfg.Exchange.Name, cfg.Coins, creds)
+		paper := trade.NewPaperExecutor(market, paperConfig)
+		t.provider, t.executor = paper, paper
+	case *shadowMode:
+		log.Println("... 👻 影子模式: 订单只写入审计日志，不会发送到交易所")
+		paperConfig.PersistencePath = cfg.Shadow.AccountPath
+		auditPath := lo.Ternary(dryRun, "", cfg.Shadow.AuditPath)
+		market = collector.ResolveMarketCollector(cfg.Exchange.Name, cfg.Coins, creds)
+		shadow, err := trade.NewShadowExecutor(cfg.Exchange.Name, creds, market, paperConfig, auditPath)
+		if err != nil {
+			return nil, err
+		}
+		t.provider, t.executor = shadow, shadow
+	default:
+		executor, err := trade.ResolveExecutor(cfg.Exchange.Name, creds)
+		if err != nil {
+			return nil, fmt.Errorf("无法创建 Trade Executor: %w", err)
+		}
+		market = collector.ResolveCollector(cfg.Exchange.Name, cfg.Coins, creds)
+		t.provider, t.executor = market, executor
+	}
+	t.market, _ = market.(collector.MarketQuerier)
+
+	if dryRun {
+		t.store, t.journal = config.NewSnapshot(paths.Store), journal.NewSnapshot(paths.Journal, t.executor)
+		// dry-run 同样会实际调用 LLM 并产生费用，费用照常记入账本
+		t.ledger, t.decisions = cost.New(paths.Usage), decisionlog.New("")
+	} else {
+		t.store, t.journal = config.NewPersistence(paths.Store), journal.New(paths.Journal, t.executor)
+		t.ledger, t.decisions = cost.New(paths.Usage), decisionlog.New(paths.DecisionLog)
+	}
+	t.manager = trade.NewManager(t.store)
+	t.breaker = newCircuitBreaker(t.store)
+	return t, nil
+}
+
+// newPaperConfig 返回模拟账户的撮合参数，账户文件路径由调用方按模式设置
+func newPaperConfig(cfg *config.Config) trade.PaperConfig {
+	return trade.PaperConfig{
+		InitialBalance:        cfg.Paper.InitialBalance,
+		SlippageRate:          cfg.Paper.SlippageRate,
+		TakerFeeRate:          cfg.Paper.TakerFeeRate,
+		MaintenanceMarginRate: cfg.Paper.MaintenanceMarginRate,
+		FundingInterval:       cfg.Paper.FundingInterval,
+	}
+}
+
+// loadAgent 创建使用 model 的 AI Agent，组合分析与 trader 共用同一份持久化状态。
+// model 为 model.name 且配置了 ensemble.models 时创建集成决策；启用 model.tools 时模型可以调用 t.tools 中的工具。
+func (t *trader) loadAgent(model string) error {
+	cfg := config.App
+	opts := []llm.Option{llm.WithPersistence(t.store)}
+	if cfg.Model.Tools.Enabled {
+		opts = append(opts, llm.WithTools(t.tools(), cfg.Model.Tools.MaxCalls))
+	}
+
+	var err error
+	if ensemble := cfg.Ensemble; model == cfg.Model.Name && len(ensemble.Models) > 0 {
+		t.agent, err = llm.NewEnsemble(cfg.Exchange.Name, cfg.Coins, ensemble.Models, ensemble.Quorum,
+			t.provider.GetStartingCapital(), opts...)
+	} else {
+		t.agent, err = llm.NewAgent(cfg.Exchange.Name, cfg.Coins, model,
+			t.provider.GetStartingCapital(), opts...)
+	}
+	if err != nil {
+		return fmt.Errorf("无法创建 AI Agent: %w", err)
+	}
+
+	if cfg.Reviewer.Model != "" {
+		if t.reviewer, err = llm.NewReviewer(cfg.Exchange.Name, cfg.Coins, cfg.Reviewer.Model); err != nil {
+			return fmt.Errorf("无法创建复核模型: %w", err)
 		}
-		runDecisionCycle(ctx, provider, agent, tradeManager, tradeExecutor)
 	}
+	return nil
+}
+
+func newRiskEngine() *risk.Engine {
+	return risk.NewEngine(risk.Rules{
+		MaxLeverage:       config.App.Risk.MaxLeverage,
+		MaxNotionalPct:    config.App.Risk.MaxNotionalPct,
+		MaxExposurePct:    config.App.Risk.MaxExposurePct,
+		MaxRiskPct:        config.App.Risk.MaxRiskPct,
+		MinLiqDistancePct: config.App.Risk.MinLiqDistancePct,
+		MaintenanceMargin: config.App.Paper.MaintenanceMarginRate,
+		MinNotional:       config.App.Risk.MinNotionalUSD,
+	})
+}
+
+func newCircuitBreaker(store *config.Persistence) *risk.CircuitBreaker {
+	return risk.NewCircuitBreaker(risk.BreakerConfig{
+		MaxDailyDrawdownPct: config.App.Breaker.MaxDailyDrawdownPct,
+		MaxDrawdownPct:      config.App.Breaker.MaxDrawdownPct,
+		Cooldown:            config.App.Breaker.Cooldown,
+		FlattenOnTrip:       config.App.Breaker.FlattenOnTrip,
+	}, store)
 }
 
 /**
  * runDecisionCycle 封装了单次决策的完整流程
  */
-func runDecisionCycle(
-	ctx context.Context,
-	provider collector.StateProvider,
-	agent *llm.Agent,
-	tradeManager *trade.Manager,
-	tradeExecutor *trade.Executor,
-) {
-	log.Println("----------- 决策周期开始 -----------")
+func (t *trader) runDecisionCycle(ctx context.Context) {
+	cycleID := decisionlog.NewCycleID(utils.Now())
+	log.Printf("----------- 决策周期开始 [%s] -----------", cycleID)
 	defer log.Println("----------- 决策周期结束 -----------")
 
 	// --- 步骤 1: 数据采集 ---
-	log.Println("🔄 1. [数据采集] 正在从 Binance 获取最新市场数据...")
-	data, err := provider.AssemblePromptData(ctx)
+	log.Println("🔄 1. [数据采集] 正在获取最新市场数据...")
+	data, err := t.provider.AssemblePromptData(ctx)
 	if err != nil {
 		log.Printf("❌ [数据采集] 错误: %v", err)
 		return // 非致命错误，等待下个周期
 	}
 	log.Printf("✅ 1. [数据采集] 完成。账户价值: $%.2f", data.Account.AccountValue)
 
-	// --- 步骤 2: 状态合并 ---
-	log.Println("🔄 2. [状态合并] 正在合并本地元数据与交易所持仓...")
-	mergedPositions := 0
-	// 暂不考虑 "僵尸" 持仓 的情况
-	for idx, position := range data.Positions {
-		meta, exists := tradeManager.Get(position.Symbol)
-		if !exists {
-			// 这是 API 有持仓，但我们本地没有元数据的情况 (僵尸持仓)
-			// 根据你的要求，我们暂不处理，直接跳过
-			continue
-		}
-
-		// 假设 data.Positions[idx] 的结构体中已经包含了 ExitPlan, Confidence 等字段
-		// 注意：这要求 entity.PositionData 结构体被修改过，以包含这些字段
-		data.Positions[idx].ExitPlan.ProfitTarget = meta.ProfitTarget
-		data.Positions[idx].ExitPlan.StopLoss = meta.StopLoss
-		data.Positions[idx].ExitPlan.InvalidCond = meta.InvalidationCondition
-		data.Positions[idx].Confidence = meta.Confidence
-		data.Positions[idx].RiskUSD = meta.RiskUSD
-		data.Positions[idx].AgeInMinutes = time.Since(meta.EntryTime).Minutes()
+	// --- 熔断检查 ---
+	t.mu.Lock()
+	if t.breaker.Observe(data.Account.AccountValue) && t.breaker.ShouldFlatten() {
+		log.Println("🚨 [熔断] 正在平掉所有持仓...")
+		data.Positions = t.flatten(ctx, data.Positions, entity.CloseReasonBreaker)
+	}
+	allowEntries, breakerReason := t.breaker.AllowEntries()
+	if !allowEntries {
+		log.Printf("⛔ [熔断] 禁止开新仓: %s", breakerReason)
+	}
 
-		log.Printf("   ... 合并持仓 %s (已持仓 %.0f 分钟)", position.Symbol, data.Positions[idx].AgeInMinutes)
-		mergedPositions++
+	// --- 步骤 2: 状态合并 ---
+	// 熔断期间也要对账，及时移除已在交易所平掉的持仓并记入交易日志
+	log.Println("🔄 2. [状态合并] 正在对账并合并本地元数据与交易所持仓...")
+	for _, closed := range t.manager.Reconcile(ctx, t.executor, data.Positions) {
+		t.journal.Record(ctx, closed)
+	}
+	data.RecentTrades = t.journal.Recent(config.App.Trading.RecentTradesLimit)
+	mergedPositions := t.mergeMetadata(data.Positions)
+	if t.triggers != nil {
+		t.triggers.Reset(data)
+	}
+	t.mu.Unlock()
+	if !allowEntries && len(data.Positions) == 0 {
+		log.Println("   ... 熔断中且当前无持仓，跳过本周期的 AI 分析。")
+		return
 	}
 	log.Printf("✅ 2. [状态合并] 完成。共合并 %d 个持仓的元数据。", mergedPositions)
 
 	// --- 步骤 3: AI 分析 ---
 	log.Println("🧠 3. [AI分析] 正在将数据提交给 LLM 进行分析...")
-	timeoutCtx, cancel := context.WithTimeout(ctx, config.KlineInterval-time.Minute)
+	timeoutCtx, cancel := context.WithTimeout(ctx, config.App.Market.KlineInterval-time.Minute)
 	defer cancel()
-	decision, err := agent.RunAnalysis(timeoutCtx, data)
+	audit := decisionlog.Record{CycleID: cycleID, Time: utils.Now(), Data: data}
+	defer func() {
+		if err := t.decisions.Write(audit); err != nil {
+			log.Printf("⚠️ [审计] 无法写入周期 %s 的决策审计记录: %v", cycleID, err)
+		}
+	}()
+	decision, trace, err := t.agent.RunAnalysis(timeoutCtx, data)
+	audit.Trace = trace
+	// 每个被调用的模型一条费用记录，本周期的错误与成交数记在最后一条上
+	records := lo.Map(trace.Usage, func(usage entity.Usage, _ int) cost.Record {
+		return cost.Record{Time: audit.Time, CycleID: cycleID, Usage: usage}
+	})
+	record := &cost.Record{}
+	if len(records) > 0 {
+		record = &records[len(records)-1]
+	}
+	defer func() {
+		for _, r := range records {
+			t.ledger.Add(r)
+		}
+	}()
 	if err != nil {
+		// 回放未命中说明本次会话已经与录制时不同，继续运行只会得到无法复现的结果
+		if errors.Is(err, llm.ErrCassetteMiss) {
+			log.Fatalf("❌ [回放] %v", err)
+		}
+		audit.Error, record.Error = err.Error(), err.Error()
 		log.Printf("❌ [AI分析] 错误: %v", err)
 		return // AI 分析失败，等待下个周期
 	}
 
-	log.Println("✅ 3. [AI分析] 完成。AI 投资组合分析摘要: ", decision.PortfolioAnalysis)
+	log.Printf("✅ 3. [AI分析] 完成 (%s)。AI 投资组合分析摘要: %s", decision.Model, decision.PortfolioAnalysis)
+	parsed := decision // 之后的过滤会修改 decision.Actions，审计记录保留模型给出的原始决策
+	audit.Model, audit.Decision = decision.Model, &parsed
 
 	// --- 步骤 4: 决策与执行 ---
 	log.Printf("🤖 4. [AI决策] 收到 %d 个决策。", len(decision.Actions))
@@ -120,11 +382,45 @@ func runDecisionCycle(
 	}
 
 	decision.Actions = lo.Filter(decision.Actions, func(action entity.TradeSignal, _ int) bool {
-		return action.Confidence >= 0.3
+		if !allowEntries && action.Signal != "close" {
+			log.Printf("   ... ⛔ [熔断] 忽略开仓信号: %s %s", action.Signal, action.Coin)
+			return false
+		}
+		return action.Confidence >= config.App.Trading.MinConfidence
 	})
 
-	log.Println("📈 5. [交易执行] 正在处理决策...")
+	if t.reviewer != nil && len(decision.Actions) > 0 {
+		log.Printf("🧑‍⚖️ [复核] 正在由 %s 复核 %d 个决策...", t.reviewer.Model(), len(decision.Actions))
+		actions, verdicts, reviewTrace, err := t.reviewer.Review(timeoutCtx, data, decision)
+		audit.Review = &decisionlog.Review{Model: t.reviewer.Model(), Trace: reviewTrace, Verdicts: verdicts}
+		for _, usage := range reviewTrace.Usage {
+			t.ledger.Add(cost.Record{Time: audit.Time, CycleID: cycleID, Usage: usage})
+		}
+		if err != nil {
+			if errors.Is(err, llm.ErrCassetteMiss) {
+				log.Fatalf("❌ [回放] %v", err)
+			}
+			audit.Review.Error = err.Error()
+			log.Printf("❌ [复核] 错误: %v，本周期不执行任何决策", err)
+			return
+		}
+		decision.Actions = actions
+		log.Printf("✅ [复核] 完成。%d 个决策通过。", len(decision.Actions))
+	}
+
+	log.Println("🛡️ 5. [风控审查] 正在检查杠杆、仓位规模与止盈止损...")
+	verdicts := t.risk.Review(decision.Actions, data)
+	decision.Actions = lo.FilterMap(verdicts, func(v risk.Verdict, _ int) (entity.TradeSignal, bool) {
+		return v.Action, v.Approved
+	})
+	log.Printf("✅ 5. [风控审查] 完成。%d 个决策通过。", len(decision.Actions))
+	audit.Actions = decision.Actions
+
+	log.Println("📈 6. [交易执行] 正在处理决策...")
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	for _, action := range decision.Actions {
+		execution := decisionlog.Execution{Signal: action.Signal, Coin: action.Coin, DryRun: t.dryRun}
 		switch action.Signal {
 		case "buy_to_enter", "sell_to_enter":
 			// --- 修改后的日志 ---
@@ -138,34 +434,123 @@ func runDecisionCycle(
 			log.Printf("   ...    └─ 理由: %s", action.Justification)
 			// --- 日志结束 ---
 
-			execErr := tradeExecutor.Order(ctx, action)
+			if t.dryRun {
+				log.Println("   ... 🧪 [Dry Run] 未发送订单。")
+				break
+			}
+			execErr := t.executor.Order(ctx, action)
 			if execErr == nil {
-				tradeManager.Add(action) // 交易成功, *更新本地状态*
+				record.Executed++
+				t.manager.Add(action) // 交易成功, *更新本地状态*
 				log.Printf("   ... ✅ [开仓] 订单执行成功，已添加 %s 到持仓管理器。", action.Coin)
 			} else {
+				execution.Error = execErr.Error()
 				log.Printf("   ... ❗ [开仓] 订单执行失败: %s, 错误: %v", action.Coin, execErr)
 			}
 		case "close":
 			log.Printf("   ... 🟥 [平仓] 信号: %s, 币种: %s", action.Signal, action.Coin)
 			log.Printf("   ...    └─ 理由: %s", action.Justification)
 
-			execErr := tradeExecutor.CloseOrder(ctx, action.Coin)
-			if execErr == nil {
-				tradeManager.Remove(action.Coin) // 交易成功, *更新本地状态*
+			if t.dryRun {
+				log.Println("   ... 🧪 [Dry Run] 未发送订单。")
+				break
+			}
+			if execErr := t.closePosition(ctx, action.Coin, entity.CloseReasonSignal); execErr == nil {
+				record.Executed++
 				log.Printf("   ... ✅ [平仓] 订单执行成功，已从持仓管理器移除 %s。", action.Coin)
 			} else {
+				execution.Error = execErr.Error()
 				log.Printf("   ... ❗ [平仓] 订单执行失败: %s, 错误: %v", action.Coin, execErr)
 			}
 		}
+		audit.Executions = append(audit.Executions, execution)
 	}
 }
 
+// mergeMetadata 把本地记录的退出计划、信心等元数据合并到交易所持仓中，返回合并的持仓数
+func (t *trader) mergeMetadata(positions []entity.PositionData) int {
+	merged := 0
+	for idx, position := range positions {
+		meta, exists := t.manager.Get(position.Symbol)
+		if !exists {
+			continue
+		}
+
+		positions[idx].ExitPlan.ProfitTarget = meta.ProfitTarget
+		positions[idx].ExitPlan.StopLoss = meta.StopLoss
+		positions[idx].ExitPlan.InvalidCond = meta.InvalidationCondition
+		positions[idx].Confidence = meta.Confidence
+		positions[idx].RiskUSD = meta.RiskUSD
+		positions[idx].AgeInMinutes = utils.Now().Sub(meta.EntryTime).Minutes()
+
+		log.Printf("   ... 合并持仓 %s (已持仓 %.0f 分钟)", position.Symbol, positions[idx].AgeInMinutes)
+		merged++
+	}
+	return merged
+}
+
+// closePosition 平掉 coin 的持仓，成功后移除本地元数据并记录到交易日志
+// 持仓已经在交易所平掉时 (例如两次决策之间止盈止损触发) 按交易所记录的原因移除元数据，而不是记为 reason。
+func (t *trader) closePosition(ctx context.Context, coin string, reason entity.CloseReason) error {
+	err := t.executor.CloseOrder(ctx, coin)
+	if errors.Is(err, trade.ErrNoPosition) {
+		if closed, ok := t.manager.ReconcileClosed(ctx, t.executor, coin); ok {
+			t.journal.Record(ctx, closed)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if closed, ok := t.manager.Remove(coin, reason); ok {
+		t.journal.Record(ctx, closed)
+	}
+	return nil
+}
+
+// closeInvalidated 在失效条件监控触发时平掉 coin 的持仓，持仓已被决策周期平掉时不做任何事
+func (t *trader) closeInvalidated(ctx context.Context, coin string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.manager.Get(coin); !ok {
+		return nil
+	}
+	if t.dryRun {
+		log.Printf("   ... 🧪 [Dry Run] 跳过平仓: %s", coin)
+		return nil
+	}
+	return t.closePosition(ctx, coin, entity.CloseReasonInvalidation)
+}
+
+// flatten 平掉 positions 中的所有持仓，返回平仓失败、仍然存在的持仓
+func (t *trader) flatten(ctx context.Context, positions []entity.PositionData, reason entity.CloseReason) []entity.PositionData {
+	var remaining []entity.PositionData
+	for _, position := range positions {
+		if t.dryRun {
+			log.Printf("   ... 🧪 [Dry Run] 跳过平仓: %s", position.Symbol)
+			remaining = append(remaining, position)
+			continue
+		}
+		if err := t.closePosition(ctx, position.Symbol, reason); err != nil {
+			log.Printf("   ... ❗ [平仓] 订单执行失败: %s, 错误: %v", position.Symbol, err)
+			remaining = append(remaining, position)
+			continue
+		}
+		log.Printf("   ... ✅ [平仓] %s 已平仓。", position.Symbol)
+	}
+	return remaining
+}
+
 func delay(ctx context.Context) error {
-	next := time.Now().Truncate(config.KlineInterval).Add(config.KlineInterval)
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(time.Until(next)):
+	case <-time.After(time.Until(nextCycleTime())):
 		return nil
 	}
 }
+
+// nextCycleTime 返回下一次定时决策的时间 (下一根 K 线的开盘时间)
+func nextCycleTime() time.Time {
+	return time.Now().Truncate(config.App.Market.KlineInterval).Add(config.App.Market.KlineInterval)
+}
@@ -0,0 +1,28 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// repairPromptTemplate 在模型的上一次回答无法解析或违反约束时作为追加消息发送
+const repairPromptTemplate = `Your previous response was rejected. It violated the following rules:
+
+{violations}
+
+Return a corrected decision as a **single, valid JSON object** with exactly the same structure as before ({"portfolio_analysis": "...", "actions": [...]}).
+- Fix every violation listed above. If an action cannot be fixed, remove it from "actions".
+- Keep every action that was not listed unchanged.
+- Do NOT add any text outside the JSON object.`
+
+// BuildRepairPrompt 把校验失败的原因逐条列出，要求模型给出修正后的决策
+func BuildRepairPrompt(violations []string) string {
+	var sb strings.Builder
+	for i, v := range violations {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s", i+1, v))
+	}
+	return strings.Replace(repairPromptTemplate, "{violations}", sb.String(), 1)
+}
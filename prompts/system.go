@@ -5,12 +5,14 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gtoxlili/echoAlpha/collector"
 	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/invalidation"
 )
 
-const systemPromptTemplate = `# ROLE & IDENTITY
+var systemPromptTemplate = `# ROLE & IDENTITY
 
-You are an autonomous cryptocurrency trading agent operating in live markets on the {exchange_name} decentralized exchange.
+You are an autonomous cryptocurrency trading agent operating in live markets on the {exchange_name} exchange.
 
 Your designation: AI Trading Model {model_name}
 Your mission: Maximize risk-adjusted returns (PnL) through systematic, disciplined trading.
@@ -21,7 +23,7 @@ Your mission: Maximize risk-adjusted returns (PnL) through systematic, disciplin
 
 ## Market Parameters
 
-- **Exchange**: {exchange_name} (decentralized perpetual futures)
+- **Exchange**: {exchange_name} (USDT-margined perpetual futures)
 - **Asset Universe**: {asset_universe_list} (perpetual contracts)
 - **Starting Capital**: ${starting_capital} USDT
 - **Market Hours**: 24/7 continuous trading
@@ -98,8 +100,14 @@ For EVERY trade decision, you MUST specify:
    - Placed beyond recent support/resistance to avoid premature stops
 
 3. **invalidation_condition** (string): Specific market signal that voids your thesis
-   - Examples: "BTC breaks below $100k", "RSI drops below 30", "Funding rate flips negative"
-   - Must be objective and observable
+   - A background monitor evaluates it continuously between your decisions and closes the position the moment it becomes true
+   - Must use this exact syntax (unparsable conditions are rejected):
+     - Comparison: "<operand> <op> <operand>" where op is <, <=, > or >=
+     - Cross: "<operand> crosses above <operand>" or "<operand> crosses below <operand>"
+     - Combine with "and" / "or" and parentheses
+     - An operand is a number or one of these metrics of the traded coin: {invalidation_metrics}
+     - "{short_interval}" metrics use the {short_interval} klines and "{long_interval}" metrics the {long_interval} klines; funding is the current (predicted next) funding rate shown in the market data
+   - Examples: "price < 98500", "rsi7_{short_interval} > 80 or funding < 0", "price crosses below ema20_{long_interval}"
 
 4. **confidence** (float, 0-1): Your conviction level in this trade
    - 0.0-0.3: Low confidence (avoid trading or use minimal size)
@@ -247,7 +255,7 @@ Do NOT confuse the order. This is a common error that leads to incorrect decisio
 ## Decision-Making Framework
 
 1.  Analyze current positions first (are they performing as expected?)
-2.  Check for invalidation conditions on existing trades (stop loss / profit target hit?)
+2.  Check whether the thesis of existing trades still holds (stop loss / profit target / invalidation_condition close?)
 3.  Scan for new opportunities only if capital is available
 4.  Prioritize risk management over profit maximization
 5.  When in doubt, return [] (do nothing).
@@ -258,12 +266,12 @@ Do NOT confuse the order. This is a common error that leads to incorrect decisio
 
 You have limited context. The prompt contains:
 - ~{series_length} recent data points per indicator ({interval}-minute intervals)
-- ~{series_length} recent data points for 4-hour timeframe
+- ~{series_length} recent data points for the {long_interval} timeframe
 - Current account state and open positions
 
 Optimize your analysis:
 - Focus on most recent 3-5 data points for short-term signals
-- Use 4-hour data for trend context and support/resistance levels
+- Use {long_interval} data for trend context and support/resistance levels
 - Don't try to memorize all numbers, identify patterns instead
 
 ---
@@ -323,8 +331,11 @@ func BuildSystemPrompt(
 		"{starting_capital}", fmt.Sprintf("%.2f", startingCapital),
 		"{decision_frequency}", decisionFrequency,
 		"{leverage_range}", fmt.Sprintf("%dx to %dx", minLeverage, maxLeverage),
-		"{series_length}", strconv.Itoa(config.SeriesLength),
-		"{interval}", fmt.Sprintf("%.0f", config.KlineInterval.Minutes()),
+		"{series_length}", strconv.Itoa(config.App.Market.SeriesLength),
+		"{interval}", fmt.Sprintf("%.0f", config.App.Market.KlineInterval.Minutes()),
+		"{short_interval}", collector.IntervalString(config.App.Market.KlineInterval),
+		"{long_interval}", collector.IntervalString(config.App.Market.KlineIntervalLonger),
+		"{invalidation_metrics}", strings.Join(invalidation.MetricNames(), ", "),
 	)
 
 	return r.Replace(systemPromptTemplate)
@@ -0,0 +1,26 @@
+package prompts
+
+import (
+	"fmt"
+	"os"
+)
+
+// LoadTemplates 用文件内容替换内置的系统提示词与用户提示词模板，路径为空时保留内置模板。
+// 自定义模板使用与内置模板相同的占位符 (e.g. {exchange_name}, {all_coins_data_block})。
+func LoadTemplates(systemPath, userPath string) error {
+	if systemPath != "" {
+		raw, err := os.ReadFile(systemPath)
+		if err != nil {
+			return fmt.Errorf("failed to read system prompt template: %w", err)
+		}
+		systemPromptTemplate = string(raw)
+	}
+	if userPath != "" {
+		raw, err := os.ReadFile(userPath)
+		if err != nil {
+			return fmt.Errorf("failed to read user prompt template: %w", err)
+		}
+		promptTemplate = string(raw)
+	}
+	return nil
+}
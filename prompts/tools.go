@@ -0,0 +1,28 @@
+package prompts
+
+import (
+	"strconv"
+	"strings"
+)
+
+// toolsPromptTemplate 在启用工具调用时追加到系统提示词末尾
+const toolsPromptTemplate = `
+
+---
+
+# ON-DEMAND MARKET DATA (TOOLS)
+
+The market data above is intentionally compact. Before deciding, you may call the provided tools to fetch more:
+klines at any other interval, funding rate history, order book depth, and the full details of past closed trades.
+
+- You may make at most {max_calls} tool calls in this decision. After that, tool calls are refused.
+- Only call a tool when the data above is insufficient for a specific question; do not fetch data "just in case".
+- Tool results are raw JSON. Treat them with the same data ordering rules: OLDEST → NEWEST.
+- When you are done, reply with the final decision as a **single, valid JSON object** exactly as specified above, with no tool calls.`
+
+// BuildToolsPrompt 返回启用工具调用后的系统提示词: 移除"无法查询外部接口"的限制说明，并追加工具的使用规则
+func BuildToolsPrompt(systemPrompt string, maxCalls int) string {
+	systemPrompt = strings.Replace(systemPrompt, "- No ability to query external APIs\n", "", 1)
+	systemPrompt = strings.Replace(systemPrompt, "- No access to order book depth beyond mid-price\n", "", 1)
+	return systemPrompt + strings.Replace(toolsPromptTemplate, "{max_calls}", strconv.Itoa(maxCalls), 1)
+}
@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/gtoxlili/echoAlpha/collector"
 	"github.com/gtoxlili/echoAlpha/config"
 	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
 )
 
 // promptTemplate (主模板)
 // 关键变更：所有币种的静态部分被替换为 {all_coins_data_block}
-const promptTemplate = `It has been {minutes_elapsed} minutes since you started trading.
+var promptTemplate = `It has been {minutes_elapsed} minutes since you started trading.
 
 Below, we are providing you with a variety of collector data, price data, and predictive signals so you can discover alpha. Below that is your current account information, value, performance, positions, etc.
 
@@ -48,6 +50,14 @@ Below, we are providing you with a variety of collector data, price data, and pr
 {positions_block}
 ` + "```" + `
 
+---
+
+## RECENT TRADE OUTCOMES
+
+**These are your most recent closed trades (oldest → newest), with the justification you gave when entering. Learn from your own results: do not repeat setups that keep getting stopped out, and favour the ones that worked.**
+
+{recent_trades_block}
+
 Based on the above data, provide your trading decision in the required JSON format.
 `
 
@@ -66,7 +76,7 @@ const coinDataTemplate = `### ALL {symbol} DATA
 - Open Interest: Latest: {oi_latest} | Average: {oi_avg}
 - Funding Rate: {funding_rate}
 
-**Intraday Series (5-minute intervals, oldest → latest):**
+**Intraday Series ({short_interval} intervals, oldest → latest):**
 
 Mid prices: [{prices_3m}]
 
@@ -78,7 +88,7 @@ RSI indicators (7-Period): [{rsi7_3m}]
 
 RSI indicators (14-Period): [{rsi14_3m}]
 
-**Longer-term Context (4-hour timeframe):**
+**Longer-term Context ({long_interval} timeframe):**
 
 20-Period EMA: {ema20_4h} vs. 50-Period EMA: {ema50_4h}
 
@@ -86,9 +96,9 @@ RSI indicators (14-Period): [{rsi14_3m}]
 
 Current Volume: {volume_current} vs. Average Volume: {volume_avg}
 
-MACD indicators (4h): [{macd_4h}]
+MACD indicators ({long_interval}): [{macd_4h}]
 
-RSI indicators (14-Period, 4h): [{rsi14_4h}]
+RSI indicators (14-Period, {long_interval}): [{rsi14_4h}]
 
 ---
 `
@@ -147,6 +157,23 @@ func formatPositions(positions []entity.PositionData) string {
 	return b.String()
 }
 
+// formatRecentTrades 将最近平仓的交易格式化为列表，盈亏为扣除手续费与资金费后的净值
+func formatRecentTrades(trades []entity.ClosedTrade) string {
+	if len(trades) == 0 {
+		return "No closed trades yet."
+	}
+
+	var b strings.Builder
+	for i, t := range trades {
+		b.WriteString(fmt.Sprintf(
+			"%d. %s %s | held %.0f minutes | realized PnL: %.2f USD | exit reason: %s\n   Original justification: %s\n",
+			i+1, t.Coin, strings.ToUpper(t.Side), t.ExitTime.Sub(t.EntryTime).Minutes(),
+			t.NetPnL, t.Reason, lo.Ternary(t.Justification == "", "(unknown, position was not opened by you)", t.Justification),
+		))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
 // buildAllCoinsBlock (新增的辅助函数)
 // 动态构建所有币种的数据块
 func buildAllCoinsBlock(coins map[string]entity.CoinData) string {
@@ -184,6 +211,9 @@ func buildAllCoinsBlock(coins map[string]entity.CoinData) string {
 			"{volume_avg}", fmt.Sprintf("%.4f", coinData.VolAvg),
 			"{macd_4h}", macd4hStr,
 			"{rsi14_4h}", rsi14_4hStr,
+			// 周期与失效条件中指标名的后缀 (e.g. rsi7_5m) 写法一致
+			"{short_interval}", collector.IntervalString(config.App.Market.KlineInterval),
+			"{long_interval}", collector.IntervalString(config.App.Market.KlineIntervalLonger),
 		)
 
 		// 将币种模板应用替换并附加到主构建器
@@ -211,9 +241,12 @@ func BuildUserPrompt(data entity.PromptData, portfolio string) string {
 
 		// --- 仓位块 ---
 		"{positions_block}", positionsStr,
+		"{recent_trades_block}", formatRecentTrades(data.RecentTrades),
 		"{last_portfolio_analysis}", portfolio,
 
-		"{interval}", fmt.Sprintf("%.0f", config.KlineInterval.Minutes()),
+		"{interval}", fmt.Sprintf("%.0f", config.App.Market.KlineInterval.Minutes()),
+		"{short_interval}", collector.IntervalString(config.App.Market.KlineInterval),
+		"{long_interval}", collector.IntervalString(config.App.Market.KlineIntervalLonger),
 	)
 
 	// 4. 执行替换并返回
@@ -0,0 +1,134 @@
+package risk
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+)
+
+// BreakerConfig 描述账户级熔断的阈值
+type BreakerConfig struct {
+	MaxDailyDrawdownPct float64       // 当日 (UTC) 账户价值较日初的最大回撤
+	MaxDrawdownPct      float64       // 账户价值较历史峰值的最大回撤
+	Cooldown            time.Duration // 熔断后自动恢复的冷却时间，0 表示只能手动恢复
+	FlattenOnTrip       bool          // 熔断时是否立即平掉所有持仓
+}
+
+// CircuitBreaker 在回撤超过阈值后阻止新的开仓，直到冷却结束或被手动复位。
+// 平仓信号不受影响。手动复位通过 config.Persistence.RequestBreakerReset 请求，在下一次 Observe 时生效，
+// 峰值与日初值以那次观测到的账户价值重新计算。
+type CircuitBreaker struct {
+	cfg   BreakerConfig
+	store *config.Persistence
+
+	mu    sync.Mutex
+	state entity.BreakerState
+}
+
+func NewCircuitBreaker(cfg BreakerConfig, store *config.Persistence) *CircuitBreaker {
+	return &CircuitBreaker{
+		cfg:   cfg,
+		store: store,
+		state: store.Breaker,
+	}
+}
+
+// Observe 用本周期的账户价值更新峰值与日初值，并检查是否需要熔断。
+// 返回 true 表示本次调用刚刚触发熔断。
+func (cb *CircuitBreaker) Observe(accountValue float64) bool {
+	if accountValue <= 0 {
+		return false // 账户数据获取失败时不做判断
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	defer cb.save()
+
+	if cb.store.TakeBreakerReset() {
+		log.Println("🟢 [熔断] 已手动复位。")
+		cb.reset(0)
+	}
+	now := utils.Now()
+	if cb.state.Tripped && cb.cfg.Cooldown > 0 && now.Sub(cb.state.TrippedAt) >= cb.cfg.Cooldown {
+		log.Printf("🟢 [熔断] 冷却期 %v 已结束，恢复开仓。", cb.cfg.Cooldown)
+		cb.reset(accountValue)
+	}
+
+	// 新的交易日以及恢复后的第一个值都作为新的基准
+	if day := now.UTC().Format(time.DateOnly); day != cb.state.Day {
+		cb.state.Day = day
+		cb.state.DayStart = accountValue
+	}
+	if accountValue > cb.state.Peak {
+		cb.state.Peak = accountValue
+	}
+	if cb.state.Tripped {
+		return false
+	}
+
+	var reason string
+	if dd := drawdown(cb.state.DayStart, accountValue); cb.cfg.MaxDailyDrawdownPct > 0 && dd >= cb.cfg.MaxDailyDrawdownPct {
+		reason = fmt.Sprintf("当日回撤 %.2f%% (日初 $%.2f → $%.2f) 超过阈值 %.2f%%", dd*100, cb.state.DayStart, accountValue, cb.cfg.MaxDailyDrawdownPct*100)
+	} else if dd := drawdown(cb.state.Peak, accountValue); cb.cfg.MaxDrawdownPct > 0 && dd >= cb.cfg.MaxDrawdownPct {
+		reason = fmt.Sprintf("峰值回撤 %.2f%% (峰值 $%.2f → $%.2f) 超过阈值 %.2f%%", dd*100, cb.state.Peak, accountValue, cb.cfg.MaxDrawdownPct*100)
+	}
+	if reason == "" {
+		return false
+	}
+
+	cb.state.Tripped = true
+	cb.state.TrippedAt = now
+	cb.state.Reason = reason
+	log.Printf("🚨 [熔断] 已触发: %s", reason)
+	return true
+}
+
+// AllowEntries 返回当前是否允许开新仓，不允许时附带熔断原因
+func (cb *CircuitBreaker) AllowEntries() (bool, string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if !cb.state.Tripped {
+		return true, ""
+	}
+	reason := fmt.Sprintf("熔断于 %s: %s", cb.state.TrippedAt.Format(time.DateTime), cb.state.Reason)
+	if cb.cfg.Cooldown > 0 {
+		reason += fmt.Sprintf(" (预计 %s 恢复)", cb.state.TrippedAt.Add(cb.cfg.Cooldown).Format(time.DateTime))
+	}
+	return false, reason
+}
+
+// ShouldFlatten 返回熔断时是否需要平掉所有持仓
+func (cb *CircuitBreaker) ShouldFlatten() bool {
+	return cb.cfg.FlattenOnTrip
+}
+
+// reset 清除熔断状态并以 accountValue 作为新的基准，调用方需持有 cb.mu
+func (cb *CircuitBreaker) reset(accountValue float64) {
+	cb.state = entity.BreakerState{
+		Peak:     accountValue,
+		Day:      utils.Now().UTC().Format(time.DateOnly),
+		DayStart: accountValue,
+	}
+	if accountValue <= 0 {
+		cb.state.Day = ""
+	}
+}
+
+// save 持久化熔断状态，调用方需持有 cb.mu
+func (cb *CircuitBreaker) save() {
+	if err := cb.store.SaveBreakerState(cb.state); err != nil {
+		log.Printf("⚠️ [熔断] 无法保存熔断状态: %v", err)
+	}
+}
+
+func drawdown(base, current float64) float64 {
+	if base <= 0 {
+		return 0
+	}
+	return (base - current) / base
+}
@@ -0,0 +1,174 @@
+package risk
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+)
+
+// Rules 是下单前的风控规则，所有比例都以账户总价值 (AccountValue) 为基准
+type Rules struct {
+	MaxLeverage       int     // 杠杆上限，超出时下调
+	MaxNotionalPct    float64 // 单币种名义价值上限 (e.g. 3.0 = 300% 账户价值)，超出时缩减数量
+	MaxExposurePct    float64 // 所有持仓名义价值合计上限，超出时缩减数量
+	MaxRiskPct        float64 // 单笔止损亏损 (RiskUSD) 上限，超出时缩减数量
+	MinLiqDistancePct float64 // 强平价与入场价的最小距离，不足时下调杠杆
+	MaintenanceMargin float64 // 估算强平价使用的维持保证金率
+	MinNotional       float64 // 交易所最小名义价值 (USDT)
+}
+
+// Verdict 是风控对单个信号的裁决结果
+type Verdict struct {
+	Action      entity.TradeSignal `json:"action"` // 调整后的信号
+	Approved    bool               `json:"approved"`
+	Adjustments []string           `json:"adjustments,omitempty"` // 每一次缩减 / 下调的原因
+	VetoReason  string             `json:"veto_reason,omitempty"`
+}
+
+type Engine struct {
+	rules Rules
+}
+
+func NewEngine(rules Rules) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Review 依次审查本周期的所有信号。
+// 平仓信号直接放行；开仓信号可能被缩减数量、下调杠杆或否决。
+// 同一周期内先通过的开仓会计入后续信号的总敞口与单币种名义价值。
+func (e *Engine) Review(actions []entity.TradeSignal, data entity.PromptData) []Verdict {
+	exposure := 0.0
+	coinNotional := make(map[string]float64) // key: coin，已有持仓与本周期已通过开仓的名义价值
+	for _, p := range data.Positions {
+		exposure += math.Abs(p.NotionalUSD)
+		coinNotional[p.Symbol] += math.Abs(p.NotionalUSD)
+	}
+
+	verdicts := make([]Verdict, 0, len(actions))
+	for _, action := range actions {
+		if action.Signal != "buy_to_enter" && action.Signal != "sell_to_enter" {
+			verdicts = append(verdicts, Verdict{Action: action, Approved: true})
+			continue
+		}
+
+		verdict := e.reviewEntry(action, data, exposure, coinNotional[action.Coin])
+		if verdict.Approved {
+			notional := verdict.Action.Quantity * data.Coins[action.Coin].Price
+			exposure += notional
+			coinNotional[action.Coin] += notional
+		}
+		for _, adj := range verdict.Adjustments {
+			log.Printf("   ... 🛡️ [风控] %s 调整: %s", action.Coin, adj)
+		}
+		if !verdict.Approved {
+			log.Printf("   ... 🛡️ [风控] %s 否决: %s", action.Coin, verdict.VetoReason)
+		}
+		verdicts = append(verdicts, verdict)
+	}
+	return verdicts
+}
+
+// reviewEntry 审查单个开仓信号，exposure 为当前总敞口，coinNotional 为同一币种已有的名义价值
+func (e *Engine) reviewEntry(action entity.TradeSignal, data entity.PromptData, exposure, coinNotional float64) Verdict {
+	v := Verdict{Action: action}
+	veto := func(format string, args ...any) Verdict {
+		v.VetoReason = fmt.Sprintf(format, args...)
+		return v
+	}
+	resize := func(maxQty float64, format string, args ...any) {
+		if maxQty < v.Action.Quantity {
+			v.Adjustments = append(v.Adjustments, fmt.Sprintf("数量 %f → %f, ", v.Action.Quantity, maxQty)+fmt.Sprintf(format, args...))
+			v.Action.Quantity = maxQty
+		}
+	}
+
+	coin, ok := data.Coins[action.Coin]
+	if !ok || coin.Price <= 0 {
+		return veto("没有 %s 的当前价格", action.Coin)
+	}
+	price := coin.Price
+	equity := data.Account.AccountValue
+	if equity <= 0 {
+		return veto("账户价值无效: %.2f", equity)
+	}
+	if action.Quantity <= 0 {
+		return veto("数量必须为正数: %f", action.Quantity)
+	}
+
+	// 1. 止盈止损必须位于当前价格的正确一侧
+	long := action.Signal == "buy_to_enter"
+	if long && !(action.StopLoss < price && price < action.ProfitTarget) {
+		return veto("多头要求 止损 < 当前价 < 止盈, 实际 止损 %.4f, 当前价 %.4f, 止盈 %.4f", action.StopLoss, price, action.ProfitTarget)
+	}
+	if !long && !(action.ProfitTarget < price && price < action.StopLoss) {
+		return veto("空头要求 止盈 < 当前价 < 止损, 实际 止盈 %.4f, 当前价 %.4f, 止损 %.4f", action.ProfitTarget, price, action.StopLoss)
+	}
+	if action.StopLoss <= 0 {
+		return veto("止损价必须为正数: %.4f", action.StopLoss)
+	}
+
+	// 2. 杠杆上限
+	if v.Action.Leverage < 1 {
+		v.Adjustments = append(v.Adjustments, fmt.Sprintf("杠杆 %dx → 1x, 杠杆不能小于 1", v.Action.Leverage))
+		v.Action.Leverage = 1
+	}
+	if e.rules.MaxLeverage > 0 && v.Action.Leverage > e.rules.MaxLeverage {
+		v.Adjustments = append(v.Adjustments, fmt.Sprintf("杠杆 %dx → %dx, 超过杠杆上限", v.Action.Leverage, e.rules.MaxLeverage))
+		v.Action.Leverage = e.rules.MaxLeverage
+	}
+
+	// 3. 强平距离: 逐仓下强平距离约为 1/杠杆 - 维持保证金率
+	leverage := v.Action.Leverage
+	for leverage > 1 && e.liqDistance(leverage) < e.rules.MinLiqDistancePct {
+		leverage--
+	}
+	if leverage != v.Action.Leverage {
+		v.Adjustments = append(v.Adjustments, fmt.Sprintf("杠杆 %dx → %dx, 强平距离需至少 %.2f%%", v.Action.Leverage, leverage, e.rules.MinLiqDistancePct*100))
+		v.Action.Leverage = leverage
+	}
+	// 止损必须先于强平触发
+	stopDistance := math.Abs(price-action.StopLoss) / price
+	if stopDistance >= e.liqDistance(v.Action.Leverage) {
+		return veto("止损距离 %.2f%% 超过了 %dx 杠杆下的强平距离 %.2f%%", stopDistance*100, v.Action.Leverage, e.liqDistance(v.Action.Leverage)*100)
+	}
+
+	// 4. 单币种名义价值上限 (包含该币种已有的持仓)
+	if e.rules.MaxNotionalPct > 0 {
+		maxNotional := e.rules.MaxNotionalPct * equity
+		remaining := maxNotional - coinNotional
+		if remaining <= 0 {
+			return veto("%s 名义价值 $%.2f 已达单币种上限 $%.2f (%.0f%% 账户价值)", action.Coin, coinNotional, maxNotional, e.rules.MaxNotionalPct*100)
+		}
+		resize(remaining/price, "单币种名义价值上限 $%.2f (%.0f%% 账户价值)，剩余额度 $%.2f", maxNotional, e.rules.MaxNotionalPct*100, remaining)
+	}
+
+	// 5. 总敞口上限
+	if e.rules.MaxExposurePct > 0 {
+		remaining := e.rules.MaxExposurePct*equity - exposure
+		if remaining <= 0 {
+			return veto("总敞口 $%.2f 已达上限 (%.0f%% 账户价值)", exposure, e.rules.MaxExposurePct*100)
+		}
+		resize(remaining/price, "总敞口剩余额度 $%.2f", remaining)
+	}
+
+	// 6. 单笔风险上限 (按实际止损距离重新计算，不信任模型给出的 risk_usd)
+	if e.rules.MaxRiskPct > 0 {
+		maxRisk := e.rules.MaxRiskPct * equity
+		resize(maxRisk/(price*stopDistance), "单笔风险上限 $%.2f (%.1f%% 账户价值)", maxRisk, e.rules.MaxRiskPct*100)
+	}
+	v.Action.RiskUSD = price * stopDistance * v.Action.Quantity
+
+	// 7. 交易所最小名义价值
+	if notional := v.Action.Quantity * price; notional < e.rules.MinNotional {
+		return veto("名义价值 $%.2f 低于交易所最小值 $%.2f", notional, e.rules.MinNotional)
+	}
+
+	v.Approved = true
+	return v
+}
+
+func (e *Engine) liqDistance(leverage int) float64 {
+	return 1/float64(leverage) - e.rules.MaintenanceMargin
+}
@@ -0,0 +1,226 @@
+package risk
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+)
+
+var testRules = Rules{
+	MaxLeverage:       20,
+	MaxNotionalPct:    3.0,
+	MaxExposurePct:    6.0,
+	MaxRiskPct:        0.05,
+	MinLiqDistancePct: 0.04,
+	MaintenanceMargin: 0.004,
+	MinNotional:       5,
+}
+
+// promptData 返回账户价值 1000、BTC 与 ETH 价格均为 100 的行情，positions 为已有持仓的名义价值
+func promptData(positions map[string]float64) entity.PromptData {
+	data := entity.PromptData{
+		Coins:   map[string]entity.CoinData{"BTC": {Price: 100}, "ETH": {Price: 100}},
+		Account: entity.AccountData{AccountValue: 1000},
+	}
+	for symbol, notional := range positions {
+		data.Positions = append(data.Positions, entity.PositionData{Symbol: symbol, NotionalUSD: notional})
+	}
+	return data
+}
+
+func long(quantity, stopLoss float64, leverage int) entity.TradeSignal {
+	return entity.TradeSignal{Coin: "BTC", Signal: "buy_to_enter", Quantity: quantity, StopLoss: stopLoss, ProfitTarget: 120, Leverage: leverage}
+}
+
+func TestReviewEntry(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        func(*Rules) // 为空时使用 testRules
+		positions    map[string]float64
+		action       entity.TradeSignal
+		wantQuantity float64
+		wantLeverage int
+		wantAdjusted int    // 调整次数
+		wantVeto     string // 为空时应通过，否则否决原因中应包含的片段
+	}{
+		{
+			name:         "within limits",
+			action:       long(1, 99, 5),
+			wantQuantity: 1,
+			wantLeverage: 5,
+		},
+		{
+			name:         "leverage capped",
+			action:       long(1, 99, 50),
+			wantQuantity: 1,
+			wantLeverage: 20,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "leverage below 1 raised",
+			action:       long(1, 99, 0),
+			wantQuantity: 1,
+			wantLeverage: 1,
+			wantAdjusted: 1,
+		},
+		{
+			// 1/L - 0.004 >= 0.09 时 L 最大为 10
+			name:         "leverage reduced to meet liquidation distance",
+			rules:        func(r *Rules) { r.MinLiqDistancePct = 0.09 },
+			action:       long(1, 99, 20),
+			wantQuantity: 1,
+			wantLeverage: 10,
+			wantAdjusted: 1,
+		},
+		{
+			name:     "stop beyond liquidation",
+			action:   long(1, 90, 20),
+			wantVeto: "超过了 20x 杠杆下的强平距离",
+		},
+		{
+			name:     "stop on the wrong side",
+			action:   long(1, 101, 5),
+			wantVeto: "多头要求",
+		},
+		{
+			name:     "non-positive quantity",
+			action:   long(0, 99, 5),
+			wantVeto: "数量必须为正数",
+		},
+		{
+			// 单币种上限 $3000，已有 $2500，只剩 $500
+			name:         "coin cap includes existing notional",
+			positions:    map[string]float64{"BTC": 2500},
+			action:       long(10, 99, 5),
+			wantQuantity: 5,
+			wantLeverage: 5,
+			wantAdjusted: 1,
+		},
+		{
+			name:         "coin cap counts short positions by absolute notional",
+			positions:    map[string]float64{"BTC": -2500},
+			action:       long(10, 99, 5),
+			wantQuantity: 5,
+			wantLeverage: 5,
+			wantAdjusted: 1,
+		},
+		{
+			name:      "coin cap reached",
+			positions: map[string]float64{"BTC": 3000},
+			action:    long(1, 99, 5),
+			wantVeto:  "已达单币种上限",
+		},
+		{
+			name:         "coin cap ignores other coins",
+			positions:    map[string]float64{"ETH": 3000},
+			action:       long(10, 99, 5),
+			wantQuantity: 10,
+			wantLeverage: 5,
+		},
+		{
+			// 总敞口上限 $6000，已有 $5500，只剩 $500
+			name:         "exposure cap",
+			positions:    map[string]float64{"ETH": 3000, "SOL": 2500},
+			action:       long(10, 99, 5),
+			wantQuantity: 5,
+			wantLeverage: 5,
+			wantAdjusted: 1,
+		},
+		{
+			name:      "exposure cap reached",
+			positions: map[string]float64{"ETH": 3000, "SOL": 3000},
+			action:    long(1, 99, 5),
+			wantVeto:  "总敞口",
+		},
+		{
+			// 止损距离 2%，单笔风险上限 $50，数量最多 50 / (100 × 2%) = 25
+			name:         "risk cap",
+			action:       long(30, 98, 10),
+			wantQuantity: 25,
+			wantLeverage: 10,
+			wantAdjusted: 1,
+		},
+		{
+			// 单币种上限先缩减到 20，风险上限再缩减到 10
+			name:         "coin cap and risk cap",
+			positions:    map[string]float64{"BTC": 1000},
+			action:       long(30, 95, 10),
+			wantQuantity: 10,
+			wantLeverage: 10,
+			wantAdjusted: 2,
+		},
+		{
+			name:         "caps disabled",
+			rules:        func(r *Rules) { r.MaxNotionalPct, r.MaxExposurePct, r.MaxRiskPct = 0, 0, 0 },
+			positions:    map[string]float64{"BTC": 10000},
+			action:       long(100, 99, 5),
+			wantQuantity: 100,
+			wantLeverage: 5,
+		},
+		{
+			name:      "below exchange minimum notional",
+			positions: map[string]float64{"BTC": 2999.99},
+			action:    long(1, 99, 5),
+			wantVeto:  "低于交易所最小值",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := testRules
+			if tt.rules != nil {
+				tt.rules(&rules)
+			}
+			verdicts := NewEngine(rules).Review([]entity.TradeSignal{tt.action}, promptData(tt.positions))
+			if len(verdicts) != 1 {
+				t.Fatalf("got %d verdicts, want 1", len(verdicts))
+			}
+			v := verdicts[0]
+			if tt.wantVeto != "" {
+				if v.Approved || !strings.Contains(v.VetoReason, tt.wantVeto) {
+					t.Fatalf("approved = %v, veto reason = %q, want veto containing %q", v.Approved, v.VetoReason, tt.wantVeto)
+				}
+				return
+			}
+			if !v.Approved {
+				t.Fatalf("vetoed: %s", v.VetoReason)
+			}
+			if math.Abs(v.Action.Quantity-tt.wantQuantity) > 1e-9 {
+				t.Errorf("quantity = %v, want %v", v.Action.Quantity, tt.wantQuantity)
+			}
+			if v.Action.Leverage != tt.wantLeverage {
+				t.Errorf("leverage = %d, want %d", v.Action.Leverage, tt.wantLeverage)
+			}
+			if len(v.Adjustments) != tt.wantAdjusted {
+				t.Errorf("adjustments = %q, want %d", v.Adjustments, tt.wantAdjusted)
+			}
+			if risk := math.Abs(100-tt.action.StopLoss) * v.Action.Quantity; math.Abs(v.Action.RiskUSD-risk) > 1e-9 {
+				t.Errorf("risk_usd = %v, want %v", v.Action.RiskUSD, risk)
+			}
+		})
+	}
+}
+
+// 同一周期内先通过的开仓计入后续信号的单币种名义价值与总敞口，平仓信号直接放行
+func TestReviewAccumulatesWithinCycle(t *testing.T) {
+	actions := []entity.TradeSignal{
+		long(20, 99, 5),
+		{Coin: "ETH", Signal: "close"},
+		long(20, 99, 5), // 单币种上限 $3000，只剩 $1000
+		long(1, 99, 5),  // 单币种额度已用完
+	}
+	verdicts := NewEngine(testRules).Review(actions, promptData(nil))
+	if len(verdicts) != len(actions) {
+		t.Fatalf("got %d verdicts, want %d", len(verdicts), len(actions))
+	}
+	for i, want := range []struct {
+		approved bool
+		quantity float64
+	}{{true, 20}, {true, 0}, {true, 10}, {false, 1}} {
+		v := verdicts[i]
+		if v.Approved != want.approved || math.Abs(v.Action.Quantity-want.quantity) > 1e-9 {
+			t.Errorf("verdict %d = approved %v quantity %v (%s), want approved %v quantity %v", i, v.Approved, v.Action.Quantity, v.VetoReason, want.approved, want.quantity)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/llm"
+
+	json "github.com/bytedance/sonic"
+	"github.com/samber/lo"
+)
+
+// 工具单次返回的数据量上限，避免一次调用撑满上下文
+const (
+	maxToolKlines  = 200
+	maxToolFunding = 100
+	maxToolDepth   = 50
+	maxToolTrades  = 20
+)
+
+// toolKline 是返回给模型的 K 线，时间为开盘时间
+type toolKline struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+// tools 返回工具调用模式下模型可以使用的工具。
+// 行情工具由 t.market 提供 (没有时不提供)，历史交易详情来自 t.journal。
+func (t *trader) tools() []llm.Tool {
+	coinParam := map[string]any{"type": "string", "enum": config.App.Coins, "description": "Coin symbol, e.g. BTC"}
+	limitParam := func(upper int, description string) map[string]any {
+		return map[string]any{"type": "integer", "minimum": 1, "maximum": upper, "description": description}
+	}
+	object := func(properties map[string]any, required ...string) map[string]any {
+		return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+	}
+
+	var tools []llm.Tool
+	if t.market != nil {
+		tools = append(tools,
+			llm.Tool{
+				Name:        "get_klines",
+				Description: "Fetch OHLCV klines for a coin at any interval (oldest → newest). The last kline may still be open.",
+				Parameters: object(map[string]any{
+					"coin":     coinParam,
+					"interval": map[string]any{"type": "string", "description": "Kline interval, e.g. 1m, 15m, 1h, 1d"},
+					"limit":    limitParam(maxToolKlines, "Number of most recent klines"),
+				}, "coin", "interval", "limit"),
+				Call: func(ctx context.Context, arguments string) (any, error) {
+					var args struct {
+						Coin     string `json:"coin"`
+						Interval string `json:"interval"`
+						Limit    int    `json:"limit"`
+					}
+					if err := parseToolArgs(arguments, &args, &args.Coin); err != nil {
+						return nil, err
+					}
+					interval, err := parseInterval(args.Interval)
+					if err != nil {
+						return nil, err
+					}
+					klines, err := t.market.Klines(ctx, args.Coin, interval, clampLimit(args.Limit, maxToolKlines))
+					if err != nil {
+						return nil, err
+					}
+					return lo.Map(klines, func(k collector.Kline, _ int) toolKline {
+						return toolKline{Time: k.OpenTime, Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume}
+					}), nil
+				},
+			},
+			llm.Tool{
+				Name:        "get_funding_history",
+				Description: "Fetch the most recent settled funding rates for a coin (oldest → newest).",
+				Parameters: object(map[string]any{
+					"coin":  coinParam,
+					"limit": limitParam(maxToolFunding, "Number of most recent funding settlements"),
+				}, "coin", "limit"),
+				Call: func(ctx context.Context, arguments string) (any, error) {
+					var args struct {
+						Coin  string `json:"coin"`
+						Limit int    `json:"limit"`
+					}
+					if err := parseToolArgs(arguments, &args, &args.Coin); err != nil {
+						return nil, err
+					}
+					rates, err := t.market.FundingHistory(ctx, args.Coin, clampLimit(args.Limit, maxToolFunding))
+					if err != nil {
+						return nil, err
+					}
+					return lo.Map(rates, func(r collector.FundingRate, _ int) map[string]any {
+						return map[string]any{"time": r.Time, "rate": r.Rate}
+					}), nil
+				},
+			},
+			llm.Tool{
+				Name:        "get_order_book",
+				Description: "Fetch the current order book for a coin. Each level is [price, quantity]; bids high → low, asks low → high.",
+				Parameters: object(map[string]any{
+					"coin":  coinParam,
+					"depth": limitParam(maxToolDepth, "Number of levels on each side"),
+				}, "coin", "depth"),
+				Call: func(ctx context.Context, arguments string) (any, error) {
+					var args struct {
+						Coin  string `json:"coin"`
+						Depth int    `json:"depth"`
+					}
+					if err := parseToolArgs(arguments, &args, &args.Coin); err != nil {
+						return nil, err
+					}
+					book, err := t.market.OrderBook(ctx, args.Coin, clampLimit(args.Depth, maxToolDepth))
+					if err != nil {
+						return nil, err
+					}
+					level := func(l collector.PriceLevel, _ int) [2]float64 { return [2]float64{l.Price, l.Quantity} }
+					return map[string]any{"bids": lo.Map(book.Bids, level), "asks": lo.Map(book.Asks, level)}, nil
+				},
+			},
+		)
+	}
+
+	tools = append(tools, llm.Tool{
+		Name:        "get_closed_trades",
+		Description: "Fetch full details of recently closed trades (oldest → newest), including the original justification, invalidation condition, close reason and settled PnL.",
+		Parameters: object(map[string]any{
+			"coin":  map[string]any{"type": "string", "description": "Only return trades of this coin; empty for all coins"},
+			"limit": limitParam(maxToolTrades, "Number of most recent trades"),
+		}, "coin", "limit"),
+		Call: func(ctx context.Context, arguments string) (any, error) {
+			var args struct {
+				Coin  string `json:"coin"`
+				Limit int    `json:"limit"`
+			}
+			if err := parseToolArgs(arguments, &args, nil); err != nil {
+				return nil, err
+			}
+			trades := t.journal.Query(args.Coin, time.Time{}, time.Time{})
+			return trades[max(0, len(trades)-clampLimit(args.Limit, maxToolTrades)):], nil
+		},
+	})
+	return tools
+}
+
+// parseToolArgs 解析模型给出的参数，coin 不为 nil 时检查其是否为交易的币种并转为大写
+func parseToolArgs(arguments string, args any, coin *string) error {
+	if err := json.UnmarshalString(arguments, args); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	if coin == nil {
+		return nil
+	}
+	*coin = strings.ToUpper(*coin)
+	if !lo.Contains(config.App.Coins, *coin) {
+		return fmt.Errorf("unknown coin %q, must be one of %s", *coin, strings.Join(config.App.Coins, ", "))
+	}
+	return nil
+}
+
+// parseInterval 解析 K 线周期 (e.g. 15m, 4h, 1d)
+func parseInterval(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid interval %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute || d%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid interval %q, use e.g. 1m, 15m, 1h, 1d", s)
+	}
+	return d, nil
+}
+
+// clampLimit 把模型给出的数量限制在 [1, upper]，缺省时取 upper
+func clampLimit(n, upper int) int {
+	if n <= 0 || n > upper {
+		return upper
+	}
+	return n
+}
@@ -0,0 +1,235 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+)
+
+type bybitExecutor struct {
+	client *exchange.BybitClient
+	// filters 缓存了所有交易对的下单规则
+	filters map[string]exchange.SymbolFilter // key: symbol (e.g., "BTCUSDT")
+}
+
+func newBybitExecutor(creds exchange.Credentials) (Executor, error) {
+	if creds.APIKey == "" || creds.APISecret == "" {
+		log.Println("⚠️ [Executor] 警告: APIKey 或 SecretKey 为空。交易执行将失败。")
+	}
+	client := exchange.NewBybitClient(creds)
+
+	log.Println("🔄 [Executor] 正在从 Bybit 获取交易所精度规则...")
+	filters, err := client.Instruments(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("初始化 Executor 失败: 无法获取精度规则: %w", err)
+	}
+	log.Printf("✅ [Executor] 成功获取 %d 个交易对的精度规则。", len(filters))
+
+	return &bybitExecutor{
+		client:  client,
+		filters: filters,
+	}, nil
+}
+
+// Order 以一个市价单开仓，止损、止盈作为持仓级别的 TP/SL 随订单一起提交 (按标记价格触发)
+func (be *bybitExecutor) Order(ctx context.Context, action entity.TradeSignal) error {
+	symbol := exchange.Bybit.Symbol(action.Coin)
+	request, err := bybitEntryRequest(be.filters, symbol, action)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[Executor] 正在尝试取消 %s 的所有挂单...", symbol)
+	be.cancelAllOrders(ctx, symbol)
+
+	log.Printf("[Executor] 正在为 %s 设置 %dx 杠杆...", symbol, action.Leverage)
+	if err := be.client.SetLeverage(ctx, symbol, action.Leverage); err != nil {
+		var bybitErr *exchange.BybitError
+		if !errors.As(err, &bybitErr) || bybitErr.Code != exchange.BybitCodeLeverageNotModified {
+			return fmt.Errorf("设置杠杆失败 for %s: %w", symbol, err)
+		}
+	}
+
+	log.Printf("[Executor] 正在为 %s 提交开仓订单 (附带止损、止盈)...", symbol)
+	if _, err := be.client.CreateOrder(ctx, request); err != nil {
+		return fmt.Errorf("下单失败 for %s: %w", symbol, err)
+	}
+
+	log.Printf("[Executor] %s 下单成功 (开仓, 止损, 止盈)。", symbol)
+	return nil
+}
+
+// CloseOrder 撤销所有挂单并以 reduceOnly 市价单平掉全部持仓
+func (be *bybitExecutor) CloseOrder(ctx context.Context, coin string) error {
+	symbol := exchange.Bybit.Symbol(coin)
+	log.Printf("[Executor] 正在为 %s 准备平仓...", coin)
+
+	position, err := be.position(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("平仓失败: 无法获取 %s 的持仓信息: %w", coin, err)
+	}
+	if position.Size == 0 {
+		log.Printf("[Executor] %s 持仓已为0，无需平仓。但仍将尝试取消挂单。", coin)
+		be.cancelAllOrders(ctx, symbol)
+		return ErrNoPosition
+	}
+
+	be.cancelAllOrders(ctx, symbol)
+
+	quantity := float64(position.Size)
+	if position.Side == "Sell" {
+		quantity = -quantity
+	}
+	request := bybitCloseRequest(be.filters, symbol, quantity)
+	log.Printf("[Executor] 正在提交 %s 的市价平仓单 (Side: %s, Qty: %s)...", coin, request.Side, request.Qty)
+	if _, err := be.client.CreateOrder(ctx, request); err != nil {
+		return fmt.Errorf("市价平仓单提交失败 for %s: %w", coin, err)
+	}
+
+	log.Printf("[Executor] %s 市价平仓单提交成功。", coin)
+	return nil
+}
+
+// ProtectiveOrders 返回持仓上设置的止损、止盈价
+func (be *bybitExecutor) ProtectiveOrders(ctx context.Context, coin string) (entity.ExitPlanData, error) {
+	position, err := be.position(ctx, exchange.Bybit.Symbol(coin))
+	if err != nil {
+		return entity.ExitPlanData{}, fmt.Errorf("无法获取 %s 的持仓信息: %w", coin, err)
+	}
+	return entity.ExitPlanData{
+		ProfitTarget: float64(position.TakeProfit),
+		StopLoss:     float64(position.StopLoss),
+	}, nil
+}
+
+// CloseReason 从 since 之后的历史订单中找出最近一笔成交的平仓单，按 createType 判断平仓原因。
+// 从现在开始按 7 天一段向前查询，直到找到平仓单或越过 since。
+func (be *bybitExecutor) CloseReason(ctx context.Context, coin string, since time.Time) (entity.CloseReason, float64, error) {
+	const maxSpan = 7 * 24 * time.Hour
+	var last *exchange.BybitOrder
+	for end := time.Now(); last == nil && end.After(since); end = end.Add(-maxSpan) {
+		start := end.Add(-maxSpan)
+		if start.Before(since) {
+			start = since
+		}
+		orders, err := be.client.OrderHistory(ctx, exchange.Bybit.Symbol(coin), start, end)
+		if err != nil {
+			return entity.CloseReasonUnknown, 0, fmt.Errorf("无法获取 %s 的历史订单: %w", coin, err)
+		}
+		for i, o := range orders {
+			if o.OrderStatus != "Filled" || !(o.ReduceOnly || isBybitForceOrder(o)) {
+				continue
+			}
+			if last == nil || o.UpdatedTime > last.UpdatedTime {
+				last = &orders[i]
+			}
+		}
+	}
+	if last == nil {
+		return entity.CloseReasonUnknown, 0, nil
+	}
+
+	exitPrice := float64(last.AvgPrice)
+	switch {
+	case isBybitForceOrder(*last):
+		return entity.CloseReasonLiquidation, exitPrice, nil
+	case last.CreateType == "CreateByStopLoss" || last.CreateType == "CreateByTrailingStop" || last.StopOrderType == "StopLoss":
+		return entity.CloseReasonStopLoss, exitPrice, nil
+	case last.CreateType == "CreateByTakeProfit" || last.StopOrderType == "TakeProfit":
+		return entity.CloseReasonTakeProfit, exitPrice, nil
+	default:
+		return entity.CloseReasonManual, exitPrice, nil
+	}
+}
+
+// Settlement 从资金流水中汇总 coin 在 [from, to] 内的已实现盈亏、手续费与资金费
+func (be *bybitExecutor) Settlement(ctx context.Context, coin string, from, to time.Time) (entity.Settlement, error) {
+	var s entity.Settlement
+	transactions, err := be.client.Transactions(ctx, from, to)
+	if err != nil {
+		return s, fmt.Errorf("无法获取 %s 的资金流水: %w", coin, err)
+	}
+	symbol := exchange.Bybit.Symbol(coin)
+	for _, t := range transactions {
+		if t.Symbol != symbol {
+			continue
+		}
+		// Bybit 的 fee 与 funding 以正数表示支出，转换为收益流水的符号
+		s.RealizedPnL += float64(t.CashFlow)
+		s.Commission -= float64(t.Fee)
+		s.Funding -= float64(t.Funding)
+	}
+	s.NetPnL = s.RealizedPnL + s.Commission + s.Funding
+	return s, nil
+}
+
+func (be *bybitExecutor) position(ctx context.Context, symbol string) (exchange.BybitPosition, error) {
+	positions, err := be.client.Positions(ctx, symbol)
+	if err != nil {
+		return exchange.BybitPosition{}, err
+	}
+	for _, p := range positions {
+		if p.Symbol == symbol && p.Size != 0 {
+			return p, nil
+		}
+	}
+	return exchange.BybitPosition{Symbol: symbol}, nil
+}
+
+// cancelAllOrders 取消 symbol 的所有挂单，失败时只记录日志
+func (be *bybitExecutor) cancelAllOrders(ctx context.Context, symbol string) {
+	if err := be.client.CancelAllOrders(ctx, symbol); err != nil {
+		log.Printf("⚠️ [Executor] 取消 %s 的挂单时遇到问题: %v (如果无挂单，此错误可忽略)", symbol, err)
+	}
+}
+
+func isBybitForceOrder(o exchange.BybitOrder) bool {
+	return strings.HasPrefix(o.CreateType, "CreateByLiq") || strings.HasPrefix(o.CreateType, "CreateByAdl") ||
+		strings.HasPrefix(o.CreateType, "CreateByTakeOver")
+}
+
+// bybitEntryRequest 为开仓信号构建市价入场单，止损、止盈作为持仓级别的 TP/SL 随订单一起提交
+func bybitEntryRequest(filters map[string]exchange.SymbolFilter, symbol string, action entity.TradeSignal) (exchange.BybitOrderRequest, error) {
+	var side string
+	switch action.Signal {
+	case "buy_to_enter":
+		side = "Buy"
+	case "sell_to_enter":
+		side = "Sell"
+	default:
+		return exchange.BybitOrderRequest{}, fmt.Errorf("[Executor] 收到无效的开仓信号: %s", action.Signal)
+	}
+
+	return exchange.BybitOrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		OrderType:   "Market",
+		Qty:         exchange.FormatQuantity(filters, symbol, action.Quantity),
+		TakeProfit:  exchange.FormatPrice(filters, symbol, action.ProfitTarget),
+		StopLoss:    exchange.FormatPrice(filters, symbol, action.StopLoss),
+		TpTriggerBy: "MarkPrice", // 使用标记价格防止插针
+		SlTriggerBy: "MarkPrice",
+		TpslMode:    "Full",
+	}, nil
+}
+
+// bybitCloseRequest 构建平掉 quantity (正多负空) 持仓的 reduceOnly 市价单
+func bybitCloseRequest(filters map[string]exchange.SymbolFilter, symbol string, quantity float64) exchange.BybitOrderRequest {
+	side, amount := "Sell", quantity
+	if quantity < 0 {
+		side, amount = "Buy", -quantity
+	}
+	return exchange.BybitOrderRequest{
+		Symbol:     symbol,
+		Side:       side,
+		OrderType:  "Market",
+		Qty:        exchange.FormatQuantity(filters, symbol, amount),
+		ReduceOnly: true,
+	}
+}
@@ -4,35 +4,30 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/samber/lo"
 )
 
-const (
-	usdtSuffix = "USDT"
-)
-
-// SymbolPrecisions 用于存储从 /exchangeInfo 获取的精度规则
-type SymbolPrecisions struct {
-	QuantityPrecision int // 数量精度 (e.g., 3 -> 0.001)
-	PricePrecision    int // 价格精度 (e.g., 2 -> 0.01)
-}
-
-type Executor struct {
+type binanceExecutor struct {
 	client *futures.Client
 	// precisions 缓存了所有交易对的精度规则
-	precisions map[string]SymbolPrecisions // key: symbol (e.g., "BTCUSDT")
+	precisions map[string]exchange.SymbolFilter // key: symbol (e.g., "BTCUSDT")
 }
 
-func NewExecutor(apiKey, secretKey string) (*Executor, error) {
-	if apiKey == "" || secretKey == "" {
+func newBinanceExecutor(creds exchange.Credentials) (Executor, error) {
+	if creds.APIKey == "" || creds.APISecret == "" {
 		log.Println("⚠️ [Executor] 警告: APIKey 或 SecretKey 为空。交易执行将失败。")
 	}
-	client := futures.NewClient(apiKey, secretKey)
+	client := futures.NewClient(creds.APIKey, creds.APISecret)
+	if creds.BaseURL != "" {
+		client.BaseURL = creds.BaseURL
+	}
 
 	// --- 1. 获取并缓存精度规则 (解决问题1) ---
 	log.Println("🔄 [Executor] 正在从 Binance 获取交易所精度规则...")
@@ -42,24 +37,17 @@ func NewExecutor(apiKey, secretKey string) (*Executor, error) {
 	}
 	log.Printf("✅ [Executor] 成功获取 %d 个交易对的精度规则。", len(precisions))
 
-	return &Executor{
+	return &binanceExecutor{
 		client:     client,
 		precisions: precisions,
 	}, nil
 }
 
-func (te *Executor) Order(ctx context.Context, action entity.TradeSignal) error {
-	symbol := action.Coin + usdtSuffix
-	var entrySide, closeSide futures.SideType
-
-	if action.Signal == "buy_to_enter" {
-		entrySide = futures.SideTypeBuy
-		closeSide = futures.SideTypeSell
-	} else if action.Signal == "sell_to_enter" {
-		entrySide = futures.SideTypeSell
-		closeSide = futures.SideTypeBuy
-	} else {
-		return fmt.Errorf("[Executor] 收到无效的开仓信号: %s", action.Signal)
+func (te *binanceExecutor) Order(ctx context.Context, action entity.TradeSignal) error {
+	symbol := exchange.Binance.Symbol(action.Coin)
+	batch, err := entryBatch(te.precisions, symbol, action)
+	if err != nil {
+		return err
 	}
 
 	log.Printf("[Executor] 正在尝试取消 %s 的所有挂单 (SL/TP)...", symbol)
@@ -70,7 +58,7 @@ func (te *Executor) Order(ctx context.Context, action entity.TradeSignal) error
 
 	// --- 1. 设置杠杆 ---
 	log.Printf("[Executor] 正在为 %s 设置 %dx 杠杆...", symbol, action.Leverage)
-	_, err := te.client.NewChangeLeverageService().
+	_, err = te.client.NewChangeLeverageService().
 		Symbol(symbol).
 		Leverage(action.Leverage).
 		Do(ctx)
@@ -79,46 +67,12 @@ func (te *Executor) Order(ctx context.Context, action entity.TradeSignal) error
 	}
 	log.Printf("[Executor] %s 杠杆设置成功。", symbol)
 
-	quantityStr := te.formatQuantity(symbol, action.Quantity)
-	stopLossStr := te.formatPrice(symbol, action.StopLoss)
-	profitTargetStr := te.formatPrice(symbol, action.ProfitTarget)
-
-	orderServices := make([]*futures.CreateOrderService, 0, 3)
-
-	// 订单 1: 市价入场单
-	entryOrder := te.client.NewCreateOrderService().
-		Symbol(symbol).
-		Side(entrySide).
-		Type(futures.OrderTypeMarket).
-		Quantity(quantityStr)
-
-	orderServices = append(orderServices, entryOrder)
-
-	// 订单 2: 止损单 (STOP_MARKET)
-	stopLossOrder := te.client.NewCreateOrderService().
-		Symbol(symbol).
-		Side(closeSide).
-		Type(futures.OrderTypeStopMarket).
-		StopPrice(stopLossStr).                    // 止损触发价
-		WorkingType(futures.WorkingTypeMarkPrice). // 使用标记价格防止插针
-		ClosePosition(true)                        // 关键：表明这是一个平仓单
-
-	orderServices = append(orderServices, stopLossOrder)
-
-	takeProfitOrder := te.client.NewCreateOrderService().
-		Symbol(symbol).
-		Side(closeSide).
-		Type(futures.OrderTypeTakeProfitMarket).
-		StopPrice(profitTargetStr). // 止盈触发价
-		WorkingType(futures.WorkingTypeMarkPrice).
-		ClosePosition(true) // 关键：表明这是一个平仓单
-
-	orderServices = append(orderServices, takeProfitOrder)
-
-	// --- 3. 批量执行 ---
+	// --- 2. 批量执行 (开仓、止损、止盈) ---
 	log.Printf("[Executor] 正在为 %s 批量提交开仓、止损、止盈订单...", symbol)
 	orders, err := te.client.NewCreateBatchOrdersService().
-		OrderList(orderServices).
+		OrderList(lo.Map(batch, func(req OrderRequest, _ int) *futures.CreateOrderService {
+			return te.newOrderService(req)
+		})).
 		Do(ctx)
 
 	if err != nil {
@@ -140,8 +94,8 @@ func (te *Executor) Order(ctx context.Context, action entity.TradeSignal) error
 // 1. 获取当前持仓
 // 2. 取消该币种所有挂单 (即 SL/TP)
 // 3. 提交一个反向的市价单来平仓
-func (te *Executor) CloseOrder(ctx context.Context, symbol string) error {
-	symbolWithSuffix := symbol + usdtSuffix
+func (te *binanceExecutor) CloseOrder(ctx context.Context, symbol string) error {
+	symbolWithSuffix := exchange.Binance.Symbol(symbol)
 	log.Printf("[Executor] 正在为 %s 准备平仓...", symbol)
 
 	// --- 1. 获取当前持仓信息 ---
@@ -164,15 +118,8 @@ func (te *Executor) CloseOrder(ctx context.Context, symbol string) error {
 
 	if quantity == 0 {
 		log.Printf("[Executor] %s 持仓已为0，无需平仓。但仍将尝试取消挂单。", symbol)
-		return te.cancelAllOrders(ctx, symbolWithSuffix)
-	}
-
-	// 确定平仓方向
-	var closeSide futures.SideType
-	if quantity > 0 { // 当前是多头 (Long)
-		closeSide = futures.SideTypeSell // 平仓需要 "Sell"
-	} else { // 当前是空头 (Short)
-		closeSide = futures.SideTypeBuy // 平仓需要 "Buy"
+		te.cancelAllOrders(ctx, symbolWithSuffix)
+		return ErrNoPosition
 	}
 
 	// --- 2. 取消所有相关挂单 (SL/TP) ---
@@ -184,17 +131,9 @@ func (te *Executor) CloseOrder(ctx context.Context, symbol string) error {
 	log.Printf("[Executor] %s 挂单取消成功。", symbol)
 
 	// --- 3. 提交市价平仓单 ---
-	// 数量必须是正数（绝对值）
-	closeQuantityStr := te.formatQuantity(symbolWithSuffix, math.Abs(quantity))
-
-	log.Printf("[Executor] 正在提交 %s 的市价平仓单 (Side: %s, Qty: %s)...", symbol, closeSide, closeQuantityStr)
-	_, err = te.client.NewCreateOrderService().
-		Symbol(symbolWithSuffix).
-		Side(closeSide).
-		Type(futures.OrderTypeMarket).
-		Quantity(closeQuantityStr).
-		ReduceOnly(true). // 关键：确保此订单只平仓，不会反向开仓
-		Do(ctx)
+	req := closeRequest(te.precisions, symbolWithSuffix, quantity)
+	log.Printf("[Executor] 正在提交 %s 的市价平仓单 (Side: %s, Qty: %s)...", symbol, req.Side, req.Quantity)
+	_, err = te.newOrderService(req).Do(ctx)
 
 	if err != nil {
 		return fmt.Errorf("市价平仓单提交失败 for %s: %w", symbol, err)
@@ -204,8 +143,129 @@ func (te *Executor) CloseOrder(ctx context.Context, symbol string) error {
 	return nil
 }
 
+// ProtectiveOrders 从当前挂单中找出止损 (STOP_MARKET) 与止盈 (TAKE_PROFIT_MARKET) 单的触发价
+func (te *binanceExecutor) ProtectiveOrders(ctx context.Context, symbol string) (entity.ExitPlanData, error) {
+	var plan entity.ExitPlanData
+	orders, err := te.client.NewListOpenOrdersService().
+		Symbol(exchange.Binance.Symbol(symbol)).
+		Do(ctx)
+	if err != nil {
+		return plan, fmt.Errorf("无法获取 %s 的挂单: %w", symbol, err)
+	}
+	for _, o := range orders {
+		stopPrice, _ := strconv.ParseFloat(o.StopPrice, 64)
+		switch o.Type {
+		case futures.OrderTypeStopMarket, futures.OrderTypeStop:
+			plan.StopLoss = stopPrice
+		case futures.OrderTypeTakeProfitMarket, futures.OrderTypeTakeProfit:
+			plan.ProfitTarget = stopPrice
+		}
+	}
+	return plan, nil
+}
+
+// CloseReason 从 since 之后的历史订单中找出最近一笔成交的平仓单:
+// 强平单的 clientOrderId 以 "autoclose-" 开头 (ADL 为 "adl_autoclose")，
+// 触发的止损 / 止盈单保留原始类型，其余的平仓市价单视为手动平仓。
+// 接口单次查询的时间跨度最长为 7 天，从现在开始按 7 天一段向前查询，直到找到平仓单或越过 since。
+func (te *binanceExecutor) CloseReason(ctx context.Context, symbol string, since time.Time) (entity.CloseReason, float64, error) {
+	const maxSpan = 7 * 24 * time.Hour
+	var last *futures.Order
+	for end := time.Now(); last == nil && end.After(since); end = end.Add(-maxSpan) {
+		start := end.Add(-maxSpan)
+		if start.Before(since) {
+			start = since
+		}
+		orders, err := te.client.NewListOrdersService().
+			Symbol(exchange.Binance.Symbol(symbol)).
+			StartTime(start.UnixMilli()).
+			EndTime(end.UnixMilli()).
+			Limit(1000).
+			Do(ctx)
+		if err != nil {
+			return entity.CloseReasonUnknown, 0, fmt.Errorf("无法获取 %s 的历史订单: %w", symbol, err)
+		}
+		for _, o := range orders {
+			if o.Status != futures.OrderStatusTypeFilled || !(o.ReduceOnly || o.ClosePosition || isForceOrder(o)) {
+				continue
+			}
+			if last == nil || o.UpdateTime > last.UpdateTime {
+				last = o
+			}
+		}
+	}
+	if last == nil {
+		return entity.CloseReasonUnknown, 0, nil
+	}
+
+	exitPrice, _ := strconv.ParseFloat(last.AvgPrice, 64)
+	switch {
+	case isForceOrder(last):
+		return entity.CloseReasonLiquidation, exitPrice, nil
+	case last.OrigType == futures.OrderTypeStopMarket || last.OrigType == futures.OrderTypeStop:
+		return entity.CloseReasonStopLoss, exitPrice, nil
+	case last.OrigType == futures.OrderTypeTakeProfitMarket || last.OrigType == futures.OrderTypeTakeProfit:
+		return entity.CloseReasonTakeProfit, exitPrice, nil
+	default:
+		return entity.CloseReasonManual, exitPrice, nil
+	}
+}
+
+// Settlement 从收益流水 (/fapi/v1/income) 中汇总 REALIZED_PNL、COMMISSION 与 FUNDING_FEE。
+// 单次查询最多返回 1000 条，长时间持仓的资金费流水可能超过该数量，按时间向后翻页直到取完。
+func (te *binanceExecutor) Settlement(ctx context.Context, symbol string, from, to time.Time) (entity.Settlement, error) {
+	const pageSize = 1000
+	var (
+		s       entity.Settlement
+		incomes []*futures.IncomeHistory
+		seen    = make(map[string]bool)
+	)
+	for start := from.UnixMilli(); ; {
+		page, err := te.client.NewGetIncomeHistoryService().
+			Symbol(exchange.Binance.Symbol(symbol)).
+			StartTime(start).
+			EndTime(to.UnixMilli()).
+			Limit(pageSize).
+			Do(ctx)
+		if err != nil {
+			return s, fmt.Errorf("无法获取 %s 的收益流水: %w", symbol, err)
+		}
+		// 下一页从本页最后一条的时间开始，同一毫秒内的流水可能在两页中重复出现
+		for _, income := range page {
+			if key := fmt.Sprintf("%d/%s/%s", income.TranID, income.IncomeType, income.TradeID); !seen[key] {
+				seen[key] = true
+				incomes = append(incomes, income)
+			}
+		}
+		if len(page) < pageSize || page[len(page)-1].Time <= start {
+			break
+		}
+		start = page[len(page)-1].Time
+	}
+	for _, income := range incomes {
+		amount, err := strconv.ParseFloat(income.Income, 64)
+		if err != nil {
+			continue
+		}
+		switch income.IncomeType {
+		case "REALIZED_PNL":
+			s.RealizedPnL += amount
+		case "COMMISSION":
+			s.Commission += amount
+		case "FUNDING_FEE":
+			s.Funding += amount
+		}
+	}
+	s.NetPnL = s.RealizedPnL + s.Commission + s.Funding
+	return s, nil
+}
+
+func isForceOrder(o *futures.Order) bool {
+	return strings.HasPrefix(o.ClientOrderID, "autoclose-") || strings.HasPrefix(o.ClientOrderID, "adl_autoclose")
+}
+
 // cancelAllOrders 是一个辅助函数，用于取消指定 symbol 的所有挂单
-func (te *Executor) cancelAllOrders(ctx context.Context, symbolWithSuffix string) error {
+func (te *binanceExecutor) cancelAllOrders(ctx context.Context, symbolWithSuffix string) error {
 	err := te.client.NewCancelAllOpenOrdersService().
 		Symbol(symbolWithSuffix).
 		Do(ctx)
@@ -220,8 +280,8 @@ func (te *Executor) cancelAllOrders(ctx context.Context, symbolWithSuffix string
 }
 
 // fetchPrecisions (新增)
-func fetchPrecisions(client *futures.Client) (map[string]SymbolPrecisions, error) {
-	precisionMap := make(map[string]SymbolPrecisions)
+func fetchPrecisions(client *futures.Client) (map[string]exchange.SymbolFilter, error) {
+	precisionMap := make(map[string]exchange.SymbolFilter)
 
 	// 使用 context.Background()，因为这是一个必须在启动时完成的关键任务
 	res, err := client.NewExchangeInfoService().Do(context.Background())
@@ -230,16 +290,20 @@ func fetchPrecisions(client *futures.Client) (map[string]SymbolPrecisions, error
 	}
 
 	for _, s := range res.Symbols {
-		var sp SymbolPrecisions
+		var sp exchange.SymbolFilter
 		for _, f := range s.Filters {
 			switch f["filterType"] {
 			case "PRICE_FILTER":
 				if tickSize, ok := f["tickSize"].(string); ok {
-					sp.PricePrecision = calcPrecision(tickSize)
+					sp.PricePrecision = exchange.Precision(tickSize)
 				}
 			case "LOT_SIZE":
 				if stepSize, ok := f["stepSize"].(string); ok {
-					sp.QuantityPrecision = calcPrecision(stepSize)
+					sp.QuantityPrecision = exchange.Precision(stepSize)
+				}
+			case "MIN_NOTIONAL":
+				if notional, ok := f["notional"].(string); ok {
+					sp.MinNotional, _ = strconv.ParseFloat(notional, 64)
 				}
 			}
 		}
@@ -248,40 +312,26 @@ func fetchPrecisions(client *futures.Client) (map[string]SymbolPrecisions, error
 	return precisionMap, nil
 }
 
-// calcPrecision (新增)
-// 将 "0.001" 这样的字符串转换为 3 (小数位数)
-func calcPrecision(stepOrTickSize string) int {
-	// 去掉末尾的 0，例如 "0.0100" -> "0.01"
-	trimmed := strings.TrimRight(stepOrTickSize, "0")
-	parts := strings.Split(trimmed, ".")
-	if len(parts) == 1 {
-		// 没有小数点 (e.g., "1"), 精度为 0
-		return 0
+// newOrderService 把 OrderRequest 转换为 Binance 的下单请求
+func (te *binanceExecutor) newOrderService(req OrderRequest) *futures.CreateOrderService {
+	service := te.client.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(req.Side).
+		Type(req.Type)
+	if req.Quantity != "" {
+		service.Quantity(req.Quantity)
 	}
-	if len(parts) == 2 {
-		// e.g., "0.01" -> "01", 长度为 2
-		return len(parts[1])
+	if req.StopPrice != "" {
+		service.StopPrice(req.StopPrice)
 	}
-	return 0 // 默认
-}
-
-// formatPrice (新增)
-func (te *Executor) formatPrice(symbol string, price float64) string {
-	prec, ok := te.precisions[symbol]
-	if !ok {
-		// 回退到旧逻辑
-		return strconv.FormatFloat(price, 'f', -1, 64)
+	if req.WorkingType != "" {
+		service.WorkingType(req.WorkingType)
 	}
-	// 使用 fmt.Sprintf 格式化到指定的小数位数
-	return fmt.Sprintf("%.*f", prec.PricePrecision, price)
-}
-
-// formatQuantity (新增)
-func (te *Executor) formatQuantity(symbol string, quantity float64) string {
-	prec, ok := te.precisions[symbol]
-	if !ok {
-		// 回退到旧逻辑
-		return strconv.FormatFloat(quantity, 'f', -1, 64)
+	if req.ClosePosition {
+		service.ClosePosition(true)
+	}
+	if req.ReduceOnly {
+		service.ReduceOnly(true)
 	}
-	return fmt.Sprintf("%.*f", prec.QuantityPrecision, quantity)
+	return service
 }
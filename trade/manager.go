@@ -2,22 +2,28 @@ package trade
 
 import (
 	"log"
+	"maps"
+	"math"
 	"sync"
-	"time"
 
 	"github.com/gtoxlili/echoAlpha/config"
 	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
 )
 
 type Manager struct {
 	mu sync.RWMutex
-	// openPositions 的 key 是 symbol (例如 "BTC"), value 是我们存储的元数据
+	// openPositions 的 key 是 symbol (例如 "BTC"), value 是我们存储的元数据。
+	// 它是 store.OpenPositions 的副本，只在 mu 下修改，通过 store 的 Save 方法持久化
 	openPositions map[string]entity.TradeMetadata
+	store         *config.Persistence
 }
 
-func NewManager() *Manager {
+func NewManager(store *config.Persistence) *Manager {
 	return &Manager{
-		openPositions: config.AppPersistence.OpenPositions,
+		openPositions: maps.Clone(store.OpenPositions),
+		store:         store,
 	}
 }
 
@@ -29,35 +35,86 @@ func (tm *Manager) Add(decision entity.TradeSignal) {
 
 	metadata := entity.TradeMetadata{
 		Symbol:                decision.Coin,
-		EntryTime:             time.Now(), // <-- 关键：在执行时记录当前时间
+		EntryTime:             utils.Now(), // <-- 关键：在执行时记录当前时间
 		ProfitTarget:          decision.ProfitTarget,
 		StopLoss:              decision.StopLoss,
 		InvalidationCondition: decision.InvalidationCondition,
 		Confidence:            decision.Confidence,
 		RiskUSD:               decision.RiskUSD,
 		Justification:         decision.Justification,
+		Side:                  lo.Ternary(decision.Signal == "buy_to_enter", "long", "short"),
+		Leverage:              decision.Leverage,
+		Quantity:              decision.Quantity,
 	}
 
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 	tm.openPositions[decision.Coin] = metadata
-	if err := config.SaveOpenPositions(tm.openPositions); err != nil {
+	if err := tm.store.SaveOpenPositions(tm.openPositions); err != nil {
 		log.Printf("Manager: Failed to save open positions: %v", err)
 	}
 	log.Printf("Manager: Added new position %s", decision.Coin)
 }
 
-// Remove 在 AI 决定平仓并且订单 *成功执行* 后被调用
-func (tm *Manager) Remove(symbol string) {
+// Remove 在持仓被平掉后调用 (AI 平仓、熔断平仓或对账发现交易所已平仓)，
+// 元数据会连同平仓原因一起记录到最近平仓列表中
+func (tm *Manager) Remove(symbol string, reason entity.CloseReason) (entity.ClosedPosition, bool) {
+	return tm.close(symbol, reason, 0)
+}
+
+func (tm *Manager) close(symbol string, reason entity.CloseReason, exitPrice float64) (entity.ClosedPosition, bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	meta, ok := tm.openPositions[symbol]
+	if !ok {
+		return entity.ClosedPosition{}, false
+	}
+	delete(tm.openPositions, symbol)
+	closed := entity.ClosedPosition{
+		TradeMetadata: meta,
+		ClosedAt:      utils.Now(),
+		Reason:        reason,
+		ExitPrice:     exitPrice,
+	}
+	if err := tm.store.SaveClosedPosition(tm.openPositions, closed); err != nil {
+		log.Printf("Manager: Failed to save open positions: %v", err)
+	}
+	log.Printf("Manager: Removed position %s (%s)", symbol, reason)
+	return closed, true
+}
+
+// fill 用交易所持仓补全开仓后才能确定的成交均价与数量
+func (tm *Manager) fill(position entity.PositionData) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	meta, ok := tm.openPositions[position.Symbol]
+	if !ok || meta.EntryPrice != 0 {
+		return
+	}
+	meta.EntryPrice = position.EntryPrice
+	meta.Quantity = math.Abs(position.Quantity)
+	tm.openPositions[position.Symbol] = meta
+	if err := tm.store.SaveOpenPositions(tm.openPositions); err != nil {
+		log.Printf("Manager: Failed to save open positions: %v", err)
+	}
+}
+
+// adopt 为交易所上存在、但本地没有元数据的持仓补上元数据
+func (tm *Manager) adopt(metadata entity.TradeMetadata) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	if _, ok := tm.openPositions[symbol]; ok {
-		delete(tm.openPositions, symbol)
-		if err := config.SaveOpenPositions(tm.openPositions); err != nil {
-			log.Printf("Manager: Failed to save open positions: %v", err)
-		}
-		log.Printf("Manager: Removed position %s", symbol)
+	tm.openPositions[metadata.Symbol] = metadata
+	if err := tm.store.SaveOpenPositions(tm.openPositions); err != nil {
+		log.Printf("Manager: Failed to save open positions: %v", err)
 	}
+	log.Printf("Manager: Adopted position %s", metadata.Symbol)
+}
+
+// Symbols 返回所有本地记录的持仓
+func (tm *Manager) Symbols() []string {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	return lo.Keys(tm.openPositions)
 }
 
 // Get 返回单个持仓的元数据
@@ -0,0 +1,78 @@
+package trade
+
+import (
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+)
+
+// OrderRequest 描述一笔待提交的 U 本位合约订单，数量与价格已按交易所精度格式化。
+// 订单类型沿用 Binance 的定义，影子模式 (ShadowExecutor) 直接把它写入审计日志。
+type OrderRequest struct {
+	Symbol        string              `json:"symbol"`
+	Side          futures.SideType    `json:"side"`
+	Type          futures.OrderType   `json:"type"`
+	Quantity      string              `json:"quantity,omitempty"`
+	StopPrice     string              `json:"stop_price,omitempty"`   // 触发价
+	WorkingType   futures.WorkingType `json:"working_type,omitempty"` // 触发价类型
+	ClosePosition bool                `json:"close_position,omitempty"`
+	ReduceOnly    bool                `json:"reduce_only,omitempty"`
+}
+
+// entryBatch 为开仓信号构建一批订单: 市价入场单、止损单 (STOP_MARKET) 与止盈单 (TAKE_PROFIT_MARKET)
+func entryBatch(filters map[string]exchange.SymbolFilter, symbol string, action entity.TradeSignal) ([]OrderRequest, error) {
+	var entrySide, closeSide futures.SideType
+	switch action.Signal {
+	case "buy_to_enter":
+		entrySide, closeSide = futures.SideTypeBuy, futures.SideTypeSell
+	case "sell_to_enter":
+		entrySide, closeSide = futures.SideTypeSell, futures.SideTypeBuy
+	default:
+		return nil, fmt.Errorf("[Executor] 收到无效的开仓信号: %s", action.Signal)
+	}
+
+	return []OrderRequest{
+		// 订单 1: 市价入场单
+		{
+			Symbol:   symbol,
+			Side:     entrySide,
+			Type:     futures.OrderTypeMarket,
+			Quantity: exchange.FormatQuantity(filters, symbol, action.Quantity),
+		},
+		// 订单 2: 止损单
+		{
+			Symbol:        symbol,
+			Side:          closeSide,
+			Type:          futures.OrderTypeStopMarket,
+			StopPrice:     exchange.FormatPrice(filters, symbol, action.StopLoss),
+			WorkingType:   futures.WorkingTypeMarkPrice, // 使用标记价格防止插针
+			ClosePosition: true,                         // 关键：表明这是一个平仓单
+		},
+		// 订单 3: 止盈单
+		{
+			Symbol:        symbol,
+			Side:          closeSide,
+			Type:          futures.OrderTypeTakeProfitMarket,
+			StopPrice:     exchange.FormatPrice(filters, symbol, action.ProfitTarget),
+			WorkingType:   futures.WorkingTypeMarkPrice,
+			ClosePosition: true,
+		},
+	}, nil
+}
+
+// closeRequest 构建平掉 quantity (正多负空) 持仓的 reduceOnly 市价单
+func closeRequest(filters map[string]exchange.SymbolFilter, symbol string, quantity float64) OrderRequest {
+	side, amount := futures.SideTypeSell, quantity // 当前是多头 (Long)，平仓需要 "Sell"
+	if quantity < 0 {
+		side, amount = futures.SideTypeBuy, -quantity
+	}
+	return OrderRequest{
+		Symbol:     symbol,
+		Side:       side,
+		Type:       futures.OrderTypeMarket,
+		Quantity:   exchange.FormatQuantity(filters, symbol, amount), // 数量必须是正数（绝对值）
+		ReduceOnly: true,                                             // 关键：确保此订单只平仓，不会反向开仓
+	}
+}
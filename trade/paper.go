@@ -0,0 +1,485 @@
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/config"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+)
+
+// PaperConfig 描述模拟账户的撮合参数
+type PaperConfig struct {
+	InitialBalance        float64
+	SlippageRate          float64       // 市价单滑点 (e.g. 0.0005 = 0.05%)，总是朝不利方向
+	TakerFeeRate          float64       // 吃单手续费率
+	MaintenanceMarginRate float64       // 维持保证金率，用于计算强平价
+	FundingInterval       time.Duration // 资金费结算间隔 (Binance 为 8 小时, UTC 对齐)
+	PersistencePath       string        // 账户状态文件，为空时只保存在内存中
+	ReadOnly              bool          // 只从 PersistencePath 恢复账户，不写回 (dry-run)
+}
+
+// Quote 是一次行情更新，High/Low 用于判断区间内是否触发止盈止损与强平
+type Quote struct {
+	Time  time.Time
+	Price float64
+	High  float64
+	Low   float64
+}
+
+// PaperTrade 是一笔已完成 (开仓 + 平仓) 的模拟交易
+type PaperTrade struct {
+	Coin       string    `json:"coin"`
+	Side       string    `json:"side"` // long / short
+	Quantity   float64   `json:"quantity"`
+	Leverage   int       `json:"leverage"`
+	EntryTime  time.Time `json:"entry_time"`
+	ExitTime   time.Time `json:"exit_time"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	Fee        float64   `json:"fee"`
+	Funding    float64   `json:"funding"` // 持仓期间支付 (负数为收取) 的资金费
+	PnL        float64   `json:"pnl"`     // 扣除手续费与资金费后的净盈亏
+	Reason     string    `json:"reason"`  // close_signal / stop_loss / take_profit / liquidation
+}
+
+type paperPosition struct {
+	Quantity     float64   `json:"quantity"` // 正数为多头，负数为空头
+	EntryPrice   float64   `json:"entry_price"`
+	Leverage     int       `json:"leverage"`
+	StopLoss     float64   `json:"stop_loss"`
+	ProfitTarget float64   `json:"profit_target"`
+	EntryTime    time.Time `json:"entry_time"`
+	Fee          float64   `json:"fee"`
+	Funding      float64   `json:"funding"`
+}
+
+type paperState struct {
+	InitialBalance float64                   `json:"initial_balance"`
+	Balance        float64                   `json:"balance"` // 钱包余额: 已实现盈亏、手续费、资金费均计入
+	Positions      map[string]*paperPosition `json:"positions"`
+	Trades         []PaperTrade              `json:"trades"`
+	LastFunding    time.Time                 `json:"last_funding"`
+	History        []float64                 `json:"history"` // 历史账户总价值，用于计算夏普比率
+}
+
+// PaperExecutor 是一个模拟账户:
+// 行情来自 market (实盘采集器或历史回放)，开平仓、止盈止损、资金费和强平都在本地撮合。
+// 它同时实现了 Executor 与 collector.StateProvider，
+// 返回的 PromptData 中账户与持仓字段来自模拟账户而非交易所。
+type PaperExecutor struct {
+	market collector.StateProvider
+	cfg    PaperConfig
+
+	mu       sync.Mutex
+	state    paperState
+	marks    map[string]float64
+	fundings map[string]float64
+}
+
+func NewPaperExecutor(market collector.StateProvider, cfg PaperConfig) *PaperExecutor {
+	pe := &PaperExecutor{
+		market:   market,
+		cfg:      cfg,
+		marks:    make(map[string]float64),
+		fundings: make(map[string]float64),
+		state: paperState{
+			InitialBalance: cfg.InitialBalance,
+			Balance:        cfg.InitialBalance,
+			Positions:      make(map[string]*paperPosition),
+		},
+	}
+	if cfg.PersistencePath != "" {
+		if file, err := os.Open(cfg.PersistencePath); err == nil {
+			var saved paperState
+			if err := json.NewDecoder(file).Decode(&saved); err == nil && saved.Positions != nil {
+				pe.state = saved
+				log.Printf("✅ [Paper] 已从 %s 恢复模拟账户: 余额 $%.2f, %d 个持仓", cfg.PersistencePath, saved.Balance, len(saved.Positions))
+			}
+			file.Close()
+		}
+	}
+	for coin, p := range pe.state.Positions {
+		pe.marks[coin] = p.EntryPrice
+	}
+	return pe
+}
+
+func (pe *PaperExecutor) GetStartingCapital() float64 {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pe.state.InitialBalance
+}
+
+// AssemblePromptData 从行情源获取市场数据，并用模拟账户的状态替换账户与持仓
+func (pe *PaperExecutor) AssemblePromptData(ctx context.Context) (entity.PromptData, error) {
+	data, err := pe.market.AssemblePromptData(ctx)
+	if err != nil {
+		return lo.Empty[entity.PromptData](), err
+	}
+	return pe.Apply(data), nil
+}
+
+// Apply 用 data 中的最新价格与资金费率驱动模拟账户 (触发止盈止损、强平、资金费)，
+// 并返回账户与持仓被替换为模拟账户状态的 PromptData
+func (pe *PaperExecutor) Apply(data entity.PromptData) entity.PromptData {
+	now := utils.Now()
+	for coin, coinData := range data.Coins {
+		if rate, err := strconv.ParseFloat(coinData.FundRate, 64); err == nil {
+			pe.mu.Lock()
+			pe.fundings[coin] = rate
+			pe.mu.Unlock()
+		}
+		pe.Update(coin, Quote{Time: now, Price: coinData.Price, High: coinData.Price, Low: coinData.Price})
+	}
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	pe.settleFunding(now)
+
+	account, positions := pe.snapshot()
+	pe.state.History = append(pe.state.History, account.AccountValue)
+	if len(pe.state.History) > config.App.Market.MaxHistoricalValues {
+		pe.state.History = pe.state.History[1:]
+	}
+	account.SharpeRatio = utils.SharpeRatio(pe.state.History)
+	pe.save()
+
+	data.Account = account
+	data.Positions = positions
+	return data
+}
+
+// Update 推进 coin 的标记价格，并按 High/Low 检查区间内是否触发强平或止盈止损
+func (pe *PaperExecutor) Update(coin string, q Quote) {
+	if q.Price <= 0 {
+		return
+	}
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	if p, exists := pe.state.Positions[coin]; exists && q.Time.After(p.EntryTime) {
+		if price, reason, hit := pe.trigger(p, q); hit {
+			log.Printf("⚡ [Paper] %s 触发 %s @ %.4f", coin, reason, price)
+			pe.closeAt(coin, price, q.Time, reason)
+			pe.save()
+		}
+	}
+	pe.marks[coin] = q.Price
+}
+
+// trigger 判断一次行情更新中最先触发的离场条件，返回成交价
+// 多头价格向下时先到达的是止损与强平中较高的那个，空头反之；同时触及止盈与止损时保守地按不利方向处理。
+// 止损单触发后按市价成交: 整个区间都已越过止损价 (例如实时模拟盘两次采样之间跳空) 时按区间内最好的价格而不是止损价成交。
+// 强平按破产价结算，即损失全部初始保证金。
+func (pe *PaperExecutor) trigger(p *paperPosition, q Quote) (float64, string, bool) {
+	liq := pe.liquidationPrice(p)
+	if p.Quantity > 0 {
+		switch {
+		case liq > 0 && q.Low <= liq && liq >= p.StopLoss:
+			return pe.bankruptcyPrice(p), "liquidation", true
+		case p.StopLoss > 0 && q.Low <= p.StopLoss:
+			return pe.slip(math.Min(p.StopLoss, q.High), -1), "stop_loss", true
+		case liq > 0 && q.Low <= liq:
+			return pe.bankruptcyPrice(p), "liquidation", true
+		case p.ProfitTarget > 0 && q.High >= p.ProfitTarget:
+			return pe.slip(p.ProfitTarget, -1), "take_profit", true
+		}
+		return 0, "", false
+	}
+	switch {
+	case liq > 0 && q.High >= liq && (p.StopLoss <= 0 || liq <= p.StopLoss):
+		return pe.bankruptcyPrice(p), "liquidation", true
+	case p.StopLoss > 0 && q.High >= p.StopLoss:
+		return pe.slip(math.Max(p.StopLoss, q.Low), 1), "stop_loss", true
+	case liq > 0 && q.High >= liq:
+		return pe.bankruptcyPrice(p), "liquidation", true
+	case p.ProfitTarget > 0 && q.Low <= p.ProfitTarget:
+		return pe.slip(p.ProfitTarget, 1), "take_profit", true
+	}
+	return 0, "", false
+}
+
+// liquidationPrice 按逐仓模式估算强平价: 亏损吃光初始保证金减去维持保证金时触发
+func (pe *PaperExecutor) liquidationPrice(p *paperPosition) float64 {
+	if p.Leverage <= 0 {
+		return 0
+	}
+	mmr := pe.cfg.MaintenanceMarginRate
+	if p.Quantity > 0 {
+		return p.EntryPrice * (1 - 1/float64(p.Leverage)) / (1 - mmr)
+	}
+	return p.EntryPrice * (1 + 1/float64(p.Leverage)) / (1 + mmr)
+}
+
+// bankruptcyPrice 是亏损恰好等于初始保证金的价格，强平单按该价格结算
+func (pe *PaperExecutor) bankruptcyPrice(p *paperPosition) float64 {
+	if p.Quantity > 0 {
+		return p.EntryPrice * (1 - 1/float64(p.Leverage))
+	}
+	return p.EntryPrice * (1 + 1/float64(p.Leverage))
+}
+
+// slip 对成交价施加滑点，side 为 1 表示买入 (价格上滑)，-1 表示卖出 (价格下滑)
+func (pe *PaperExecutor) slip(price float64, side float64) float64 {
+	return price * (1 + side*pe.cfg.SlippageRate)
+}
+
+// settleFunding 在跨过资金费结算时间点时按当时的标记价格与费率收付资金费，调用方需持有 pe.mu
+// 正费率时多头支付、空头收取
+func (pe *PaperExecutor) settleFunding(now time.Time) {
+	interval := pe.cfg.FundingInterval
+	if interval <= 0 {
+		return
+	}
+	if pe.state.LastFunding.IsZero() {
+		pe.state.LastFunding = now.Truncate(interval)
+		return
+	}
+	for next := pe.state.LastFunding.Add(interval); !next.After(now); next = next.Add(interval) {
+		for coin, p := range pe.state.Positions {
+			payment := p.Quantity * pe.marks[coin] * pe.fundings[coin]
+			p.Funding += payment
+			pe.state.Balance -= payment
+		}
+		pe.state.LastFunding = next
+	}
+}
+
+func (pe *PaperExecutor) Order(_ context.Context, action entity.TradeSignal) error {
+	var side float64
+	switch action.Signal {
+	case "buy_to_enter":
+		side = 1
+	case "sell_to_enter":
+		side = -1
+	default:
+		return fmt.Errorf("[Paper] 收到无效的开仓信号: %s", action.Signal)
+	}
+	if action.Quantity <= 0 || action.Leverage <= 0 {
+		return fmt.Errorf("[Paper] 无效的数量或杠杆: %f, %d", action.Quantity, action.Leverage)
+	}
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	if _, exists := pe.state.Positions[action.Coin]; exists {
+		return fmt.Errorf("[Paper] %s 已有持仓，不允许加仓", action.Coin)
+	}
+	mark, ok := pe.marks[action.Coin]
+	if !ok || mark <= 0 {
+		return fmt.Errorf("[Paper] %s 当前没有可用价格", action.Coin)
+	}
+
+	price := pe.slip(mark, side)
+	notional := action.Quantity * price
+	fee := notional * pe.cfg.TakerFeeRate
+	if notional/float64(action.Leverage)+fee > pe.available() {
+		return errors.New("[Paper] 可用保证金不足")
+	}
+
+	pe.state.Balance -= fee
+	pe.state.Positions[action.Coin] = &paperPosition{
+		Quantity:     side * action.Quantity,
+		EntryPrice:   price,
+		Leverage:     action.Leverage,
+		StopLoss:     action.StopLoss,
+		ProfitTarget: action.ProfitTarget,
+		EntryTime:    utils.Now(),
+		Fee:          fee,
+	}
+	pe.save()
+	log.Printf("[Paper] %s 模拟开仓成交: 数量 %f @ %.4f, 杠杆 %dx, 手续费 %.4f", action.Coin, side*action.Quantity, price, action.Leverage, fee)
+	return nil
+}
+
+func (pe *PaperExecutor) CloseOrder(_ context.Context, symbol string) error {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	p, exists := pe.state.Positions[symbol]
+	if !exists {
+		log.Printf("[Paper] %s 持仓已为0，无需平仓。", symbol)
+		return ErrNoPosition
+	}
+	price := pe.slip(pe.marks[symbol], -math.Copysign(1, p.Quantity))
+	pe.closeAt(symbol, price, utils.Now(), "close_signal")
+	pe.save()
+	return nil
+}
+
+// ProtectiveOrders 返回模拟持仓上设置的止损、止盈价
+func (pe *PaperExecutor) ProtectiveOrders(_ context.Context, symbol string) (entity.ExitPlanData, error) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	p, exists := pe.state.Positions[symbol]
+	if !exists {
+		return entity.ExitPlanData{}, nil
+	}
+	return entity.ExitPlanData{ProfitTarget: p.ProfitTarget, StopLoss: p.StopLoss}, nil
+}
+
+// CloseReason 从模拟成交记录中找出 symbol 在 since 之后最近一次平仓
+func (pe *PaperExecutor) CloseReason(_ context.Context, symbol string, since time.Time) (entity.CloseReason, float64, error) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	for i := len(pe.state.Trades) - 1; i >= 0; i-- {
+		t := pe.state.Trades[i]
+		if t.ExitTime.Before(since) {
+			break
+		}
+		if t.Coin == symbol {
+			return entity.CloseReason(t.Reason), t.ExitPrice, nil
+		}
+	}
+	return entity.CloseReasonUnknown, 0, nil
+}
+
+// Settlement 汇总 symbol 在 [from, to] 内平仓的模拟交易，符号与交易所收益流水一致
+func (pe *PaperExecutor) Settlement(_ context.Context, symbol string, from, to time.Time) (entity.Settlement, error) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	var s entity.Settlement
+	for _, t := range pe.state.Trades {
+		if t.Coin != symbol || t.ExitTime.Before(from) || t.ExitTime.After(to) {
+			continue
+		}
+		s.RealizedPnL += t.PnL + t.Fee + t.Funding
+		s.Commission -= t.Fee
+		s.Funding -= t.Funding
+		s.NetPnL += t.PnL
+	}
+	return s, nil
+}
+
+// CloseAll 以当前价格平掉所有持仓 (回测结束时使用)
+func (pe *PaperExecutor) CloseAll(reason string) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	for coin, p := range pe.state.Positions {
+		pe.closeAt(coin, pe.slip(pe.marks[coin], -math.Copysign(1, p.Quantity)), utils.Now(), reason)
+	}
+	pe.save()
+}
+
+// closeAt 以 price 平掉 coin 的全部持仓并记录成交，调用方需持有 pe.mu
+func (pe *PaperExecutor) closeAt(coin string, price float64, now time.Time, reason string) {
+	p := pe.state.Positions[coin]
+	pnl := (price - p.EntryPrice) * p.Quantity
+	fee := math.Abs(p.Quantity) * price * pe.cfg.TakerFeeRate
+
+	pe.state.Balance += pnl - fee
+	delete(pe.state.Positions, coin)
+
+	pe.state.Trades = append(pe.state.Trades, PaperTrade{
+		Coin:       coin,
+		Side:       lo.Ternary(p.Quantity > 0, "long", "short"),
+		Quantity:   math.Abs(p.Quantity),
+		Leverage:   p.Leverage,
+		EntryTime:  p.EntryTime,
+		ExitTime:   now,
+		EntryPrice: p.EntryPrice,
+		ExitPrice:  price,
+		Fee:        p.Fee + fee,
+		Funding:    p.Funding,
+		PnL:        pnl - p.Fee - fee - p.Funding,
+		Reason:     reason,
+	})
+}
+
+// PositionQuantity 返回 coin 的持仓数量 (正多负空)，无持仓时为 0
+func (pe *PaperExecutor) PositionQuantity(coin string) float64 {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	if p, exists := pe.state.Positions[coin]; exists {
+		return p.Quantity
+	}
+	return 0
+}
+
+// Trades 返回已完成的模拟交易
+func (pe *PaperExecutor) Trades() []PaperTrade {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return append([]PaperTrade(nil), pe.state.Trades...)
+}
+
+// AccountValue 返回按当前标记价格计算的账户总价值
+func (pe *PaperExecutor) AccountValue() float64 {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+	return pe.equity()
+}
+
+// equity 返回账户总价值 (钱包余额 + 未实现盈亏)，调用方需持有 pe.mu
+func (pe *PaperExecutor) equity() float64 {
+	value := pe.state.Balance
+	for coin, p := range pe.state.Positions {
+		value += (pe.marks[coin] - p.EntryPrice) * p.Quantity
+	}
+	return value
+}
+
+// available 返回扣除占用保证金后的可用资金，调用方需持有 pe.mu
+func (pe *PaperExecutor) available() float64 {
+	margin := 0.0
+	for _, p := range pe.state.Positions {
+		margin += math.Abs(p.Quantity) * p.EntryPrice / float64(p.Leverage)
+	}
+	return pe.equity() - margin
+}
+
+// snapshot 生成与实盘格式一致的账户与持仓数据，调用方需持有 pe.mu
+func (pe *PaperExecutor) snapshot() (entity.AccountData, []entity.PositionData) {
+	equity := pe.equity()
+	account := entity.AccountData{
+		CashAvailable: pe.available(),
+		AccountValue:  equity,
+	}
+	if pe.state.InitialBalance > 0 {
+		account.ReturnPct = (equity - pe.state.InitialBalance) / pe.state.InitialBalance
+	}
+
+	positions := make([]entity.PositionData, 0, len(pe.state.Positions))
+	for coin, p := range pe.state.Positions {
+		mark := pe.marks[coin]
+		positions = append(positions, entity.PositionData{
+			Symbol:        coin,
+			Quantity:      p.Quantity,
+			EntryPrice:    p.EntryPrice,
+			CurrentPrice:  mark,
+			LiqPrice:      pe.liquidationPrice(p),
+			UnrealizedPNL: (mark - p.EntryPrice) * p.Quantity,
+			Leverage:      p.Leverage,
+			NotionalUSD:   p.Quantity * mark,
+		})
+	}
+	return account, positions
+}
+
+// save 将模拟账户写回文件，调用方需持有 pe.mu
+func (pe *PaperExecutor) save() {
+	if pe.cfg.PersistencePath == "" || pe.cfg.ReadOnly {
+		return
+	}
+	file, err := os.OpenFile(pe.cfg.PersistencePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		log.Printf("⚠️ [Paper] 无法保存模拟账户: %v", err)
+		return
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(&pe.state); err != nil {
+		log.Printf("⚠️ [Paper] 无法保存模拟账户: %v", err)
+	}
+}
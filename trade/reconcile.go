@@ -0,0 +1,87 @@
+package trade
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/utils"
+	"github.com/samber/lo"
+)
+
+// Reconcile 对比本地元数据与交易所的实际持仓:
+//  1. 本地有元数据但交易所已无持仓 (止盈止损触发、强平或手动平仓): 查询平仓原因并移除元数据
+//  2. 交易所有持仓但本地没有元数据 (在机器人之外开的 "僵尸" 持仓): 读取其现有的止盈止损单并接管，
+//     没有止损单保护的持仓会在日志中标记出来
+//
+// 返回本次对账中被移除的持仓
+func (tm *Manager) Reconcile(ctx context.Context, executor Executor, positions []entity.PositionData) []entity.ClosedPosition {
+	live := lo.SliceToMap(positions, func(p entity.PositionData) (string, entity.PositionData) {
+		return p.Symbol, p
+	})
+
+	var closed []entity.ClosedPosition
+	for _, symbol := range tm.Symbols() {
+		if position, ok := live[symbol]; ok {
+			tm.fill(position)
+			continue
+		}
+		if c, ok := tm.ReconcileClosed(ctx, executor, symbol); ok {
+			closed = append(closed, c)
+		}
+	}
+
+	for _, position := range positions {
+		if _, ok := tm.Get(position.Symbol); ok {
+			continue
+		}
+		exitPlan, err := executor.ProtectiveOrders(ctx, position.Symbol)
+		if err != nil {
+			log.Printf("⚠️ [对账] 无法查询 %s 的挂单: %v", position.Symbol, err)
+		}
+		tm.adopt(adoptedMetadata(position, exitPlan, utils.Now()))
+		log.Printf("🔍 [对账] 接管外部持仓 %s: 数量 %f, 入场价 %.4f, 止损 %.4f, 止盈 %.4f",
+			position.Symbol, position.Quantity, position.EntryPrice, exitPlan.StopLoss, exitPlan.ProfitTarget)
+		if exitPlan.StopLoss == 0 {
+			log.Printf("🚩 [对账] 外部持仓 %s 没有止损单保护!", position.Symbol)
+		}
+	}
+	return closed
+}
+
+// ReconcileClosed 在已知 symbol 的持仓已经在交易所平掉时 (例如 CloseOrder 返回 ErrNoPosition)，
+// 查询平仓原因并移除元数据，本地没有该持仓时返回 false
+func (tm *Manager) ReconcileClosed(ctx context.Context, executor Executor, symbol string) (entity.ClosedPosition, bool) {
+	meta, ok := tm.Get(symbol)
+	if !ok {
+		return entity.ClosedPosition{}, false
+	}
+	reason, exitPrice, err := executor.CloseReason(ctx, symbol, meta.EntryTime)
+	if err != nil {
+		log.Printf("⚠️ [对账] 无法查询 %s 的平仓原因: %v", symbol, err)
+		reason = entity.CloseReasonUnknown
+	}
+	log.Printf("🔍 [对账] %s 已在交易所平仓 (原因: %s, 成交价: %.4f)，移除本地元数据。", symbol, reason, exitPrice)
+	return tm.close(symbol, reason, exitPrice)
+}
+
+func adoptedMetadata(position entity.PositionData, exitPlan entity.ExitPlanData, now time.Time) entity.TradeMetadata {
+	invalidation := "Position was opened outside the bot and adopted during reconciliation; no invalidation condition is known."
+	if exitPlan.StopLoss == 0 {
+		invalidation = "Position was opened outside the bot and has NO stop-loss order on the exchange; review it and close it if the setup is not valid."
+	}
+	return entity.TradeMetadata{
+		Symbol:                position.Symbol,
+		EntryTime:             now,
+		ProfitTarget:          exitPlan.ProfitTarget,
+		StopLoss:              exitPlan.StopLoss,
+		InvalidationCondition: invalidation,
+		Side:                  lo.Ternary(position.Quantity > 0, "long", "short"),
+		Leverage:              position.Leverage,
+		Quantity:              math.Abs(position.Quantity),
+		EntryPrice:            position.EntryPrice,
+		Adopted:               true,
+	}
+}
@@ -0,0 +1,171 @@
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+	"github.com/gtoxlili/echoAlpha/utils"
+)
+
+// ShadowRecord 是影子模式下一次本应发送到交易所的下单操作
+type ShadowRecord struct {
+	Time     time.Time      `json:"time"`
+	Coin     string         `json:"coin"`
+	Signal   string         `json:"signal"` // buy_to_enter / sell_to_enter / close
+	Leverage int            `json:"leverage,omitempty"`
+	Orders   []OrderRequest `json:"orders,omitempty"` // Binance 的订单
+	// Bybit 的订单: 开仓为一个附带持仓级别 TP/SL 的市价单
+	BybitOrders []exchange.BybitOrderRequest `json:"bybit_orders,omitempty"`
+	Error       string                       `json:"error,omitempty"` // 虚拟账户拒绝成交的原因
+}
+
+// ShadowExecutor 按实盘的规则构建完整的订单 (交易所精度、止损止盈单，与所选交易所的实盘执行器一致)，但不发送到交易所:
+// 订单写入审计日志 (JSON Lines)，成交则在一个虚拟账户 (PaperExecutor) 中撮合。
+// 与 PaperExecutor 一样，它同时实现了 Executor 与 collector.StateProvider。
+type ShadowExecutor struct {
+	*PaperExecutor
+	venue   exchange.Venue
+	filters map[string]exchange.SymbolFilter
+
+	mu        sync.Mutex
+	auditPath string
+}
+
+// NewShadowExecutor 从交易所公开接口获取精度规则，无需 API Key。
+// market 提供实时行情，cfg 描述虚拟账户，auditPath 为空时只输出日志。
+func NewShadowExecutor(name string, creds exchange.Credentials, market collector.StateProvider, cfg PaperConfig, auditPath string) (*ShadowExecutor, error) {
+	var (
+		venue   exchange.Venue
+		filters map[string]exchange.SymbolFilter
+		err     error
+	)
+	log.Printf("🔄 [Shadow] 正在从 %s 获取交易所精度规则...", name)
+	switch name {
+	case exchange.Binance.Name:
+		client := futures.NewClient("", "")
+		if creds.BaseURL != "" {
+			client.BaseURL = creds.BaseURL
+		}
+		venue = exchange.Binance
+		filters, err = fetchPrecisions(client)
+	case exchange.Bybit.Name:
+		venue = exchange.Bybit
+		filters, err = exchange.NewBybitClient(exchange.Credentials{BaseURL: creds.BaseURL}).Instruments(context.Background())
+	default:
+		return nil, fmt.Errorf("不支持的交易所: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("初始化 Shadow Executor 失败: 无法获取精度规则: %w", err)
+	}
+	log.Printf("✅ [Shadow] 成功获取 %d 个交易对的精度规则。", len(filters))
+
+	return &ShadowExecutor{
+		PaperExecutor: NewPaperExecutor(market, cfg),
+		venue:         venue,
+		filters:       filters,
+		auditPath:     auditPath,
+	}, nil
+}
+
+// Order 按所选交易所的规则构建开仓、止损、止盈订单并写入审计日志，再以格式化后的数量与价格在虚拟账户中开仓
+func (se *ShadowExecutor) Order(ctx context.Context, action entity.TradeSignal) error {
+	symbol := se.venue.Symbol(action.Coin)
+	record := ShadowRecord{
+		Coin:     action.Coin,
+		Signal:   action.Signal,
+		Leverage: action.Leverage,
+	}
+	// 虚拟账户按交易所实际会接受的精度成交
+	virtual := action
+	if se.venue.Name == exchange.Bybit.Name {
+		request, err := bybitEntryRequest(se.filters, symbol, action)
+		if err != nil {
+			return err
+		}
+		virtual.Quantity, _ = strconv.ParseFloat(request.Qty, 64)
+		virtual.StopLoss, _ = strconv.ParseFloat(request.StopLoss, 64)
+		virtual.ProfitTarget, _ = strconv.ParseFloat(request.TakeProfit, 64)
+		record.BybitOrders = []exchange.BybitOrderRequest{request}
+	} else {
+		batch, err := entryBatch(se.filters, symbol, action)
+		if err != nil {
+			return err
+		}
+		virtual.Quantity, _ = strconv.ParseFloat(batch[0].Quantity, 64)
+		virtual.StopLoss, _ = strconv.ParseFloat(batch[1].StopPrice, 64)
+		virtual.ProfitTarget, _ = strconv.ParseFloat(batch[2].StopPrice, 64)
+		record.Orders = batch
+	}
+	err := se.PaperExecutor.Order(ctx, virtual)
+
+	record.Time = utils.Now()
+	record.Error = errorString(err)
+	se.record(record)
+	return err
+}
+
+// CloseOrder 按虚拟账户中的持仓构建 reduceOnly 市价平仓单并写入审计日志，再在虚拟账户中平仓
+func (se *ShadowExecutor) CloseOrder(ctx context.Context, coin string) error {
+	quantity := se.PositionQuantity(coin)
+	if quantity == 0 {
+		log.Printf("[Shadow] %s 虚拟持仓已为0，无需平仓。", coin)
+		return ErrNoPosition
+	}
+
+	err := se.PaperExecutor.CloseOrder(ctx, coin)
+	record := ShadowRecord{
+		Time:   utils.Now(),
+		Coin:   coin,
+		Signal: "close",
+		Error:  errorString(err),
+	}
+	if se.venue.Name == exchange.Bybit.Name {
+		record.BybitOrders = []exchange.BybitOrderRequest{bybitCloseRequest(se.filters, se.venue.Symbol(coin), quantity)}
+	} else {
+		record.Orders = []OrderRequest{closeRequest(se.filters, se.venue.Symbol(coin), quantity)}
+	}
+	se.record(record)
+	return err
+}
+
+// record 输出并追加一条审计记录，写入失败只记录日志
+func (se *ShadowExecutor) record(r ShadowRecord) {
+	for _, o := range r.Orders {
+		log.Printf("👻 [Shadow] %s %s %s 数量 %s 触发价 %s (未发送)", o.Symbol, o.Side, o.Type, o.Quantity, o.StopPrice)
+	}
+	for _, o := range r.BybitOrders {
+		log.Printf("👻 [Shadow] %s %s %s 数量 %s 止损 %s 止盈 %s (未发送)", o.Symbol, o.Side, o.OrderType, o.Qty, o.StopLoss, o.TakeProfit)
+	}
+	if se.auditPath == "" {
+		return
+	}
+
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	file, err := os.OpenFile(se.auditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("⚠️ [Shadow] 无法写入审计日志 %s: %v", se.auditPath, err)
+		return
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(r); err != nil {
+		log.Printf("⚠️ [Shadow] 无法写入审计日志 %s: %v", se.auditPath, err)
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
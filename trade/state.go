@@ -0,0 +1,41 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/gtoxlili/echoAlpha/exchange"
+)
+
+// ErrNoPosition 表示平仓时账户上已经没有持仓 (例如止盈止损已经触发)，没有提交平仓单。
+// 调用方应按交易所记录的平仓原因 (Manager.ReconcileClosed) 移除元数据，而不是记为本次平仓。
+var ErrNoPosition = errors.New("no open position")
+
+// Executor 负责把 AI 的开仓 / 平仓决策落到 (真实或模拟的) 交易所账户上
+type Executor interface {
+	// Order 执行 buy_to_enter / sell_to_enter: 市价开仓并同时挂出止损、止盈单
+	Order(ctx context.Context, action entity.TradeSignal) error
+	// CloseOrder 撤销 symbol 的所有挂单并市价平掉全部持仓，已经没有持仓时返回 ErrNoPosition
+	CloseOrder(ctx context.Context, symbol string) error
+	// ProtectiveOrders 返回 symbol 当前挂着的止损、止盈触发价，没有对应挂单时为 0
+	ProtectiveOrders(ctx context.Context, symbol string) (entity.ExitPlanData, error)
+	// CloseReason 查询 symbol 在 since 之后最近一次平仓的原因与成交均价
+	CloseReason(ctx context.Context, symbol string, since time.Time) (entity.CloseReason, float64, error)
+	// Settlement 汇总 symbol 在 [from, to] 内的已实现盈亏、手续费与资金费
+	Settlement(ctx context.Context, symbol string, from, to time.Time) (entity.Settlement, error)
+}
+
+// ResolveExecutor 按交易所名称创建对应的 Executor
+func ResolveExecutor(name string, creds exchange.Credentials) (Executor, error) {
+	switch name {
+	case exchange.Binance.Name:
+		return newBinanceExecutor(creds)
+	case exchange.Bybit.Name:
+		return newBybitExecutor(creds)
+	default:
+		return nil, fmt.Errorf("不支持的交易所: %s", name)
+	}
+}
@@ -0,0 +1,92 @@
+package trigger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// Rules 是在两次定时决策之间提前开始决策周期的条件，值为 0 时不检查对应条件
+type Rules struct {
+	PriceMoveATR            float64 // 价格较上次决策的变动超过多少个长周期 ATR14
+	ExitProximityPct        float64 // 价格与持仓止损 / 止盈的距离小于价格的多少
+	LiquidationProximityPct float64 // 价格与持仓强平价的距离小于价格的多少
+	FundingSpike            float64 // 资金费率较上次决策的绝对变化
+	OISurgePct              float64 // 持仓量较上次决策的相对变化
+}
+
+// Event 是一个成立的触发条件
+type Event struct {
+	Coin   string
+	Kind   string // price_move / stop_loss / profit_target / liquidation / funding / open_interest
+	Detail string
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %s", e.Coin, e.Detail)
+}
+
+// coinBaseline 是上次决策时一个币种的行情
+type coinBaseline struct {
+	price   float64
+	atr     float64
+	funding float64 // 为 NaN 时上次决策没有资金费率
+	oi      float64
+}
+
+func newCoinBaseline(data entity.CoinData) coinBaseline {
+	funding, err := strconv.ParseFloat(data.FundRate, 64)
+	if err != nil {
+		funding = math.NaN()
+	}
+	return coinBaseline{price: data.Price, atr: data.LongTerm.Atr144h, funding: funding, oi: data.OILatest}
+}
+
+// detect 返回 coin 当前行情 ticker 相对上次决策 base 与持仓 positions 成立的触发条件
+func (r Rules) detect(coin string, base coinBaseline, positions []entity.PositionData, ticker collector.Ticker) []Event {
+	var events []Event
+	add := func(kind, format string, args ...any) {
+		events = append(events, Event{Coin: coin, Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+	price := ticker.Price
+	if price <= 0 {
+		return nil
+	}
+
+	if move := math.Abs(price - base.price); r.PriceMoveATR > 0 && base.atr > 0 && move > r.PriceMoveATR*base.atr {
+		add("price_move", "价格较上次决策变动 %.2f ATR (%g → %g)", move/base.atr, base.price, price)
+	}
+	if diff := ticker.FundingRate - base.funding; r.FundingSpike > 0 && math.Abs(diff) >= r.FundingSpike {
+		add("funding", "资金费率较上次决策变化 %+.4f%% (%.4f%% → %.4f%%)", diff*100, base.funding*100, ticker.FundingRate*100)
+	}
+	if r.OISurgePct > 0 && base.oi > 0 && ticker.OpenInterest > 0 {
+		if change := ticker.OpenInterest/base.oi - 1; math.Abs(change) >= r.OISurgePct {
+			add("open_interest", "持仓量较上次决策变化 %+.2f%%", change*100)
+		}
+	}
+
+	for _, position := range positions {
+		if position.Symbol != coin || position.Quantity == 0 {
+			continue
+		}
+		// distance 为价格到 level 还有多少空间 (相对价格)，level 在价格下方 (below) 而价格已经跌破时为负，反之亦然
+		long := position.Quantity > 0
+		distance := func(level float64, below bool) float64 {
+			return (price - level) / price * lo.Ternary(below, 1.0, -1.0)
+		}
+		if sl := position.ExitPlan.StopLoss; r.ExitProximityPct > 0 && sl > 0 && distance(sl, long) <= r.ExitProximityPct {
+			add("stop_loss", "价格 %g 接近止损 %g", price, sl)
+		}
+		if tp := position.ExitPlan.ProfitTarget; r.ExitProximityPct > 0 && tp > 0 && distance(tp, !long) <= r.ExitProximityPct {
+			add("profit_target", "价格 %g 接近止盈 %g", price, tp)
+		}
+		if liq := position.LiqPrice; r.LiquidationProximityPct > 0 && liq > 0 && distance(liq, long) <= r.LiquidationProximityPct {
+			add("liquidation", "价格 %g 接近强平价 %g", price, liq)
+		}
+	}
+	return events
+}
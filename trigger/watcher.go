@@ -0,0 +1,131 @@
+package trigger
+
+import (
+	"context"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gtoxlili/echoAlpha/collector"
+	"github.com/gtoxlili/echoAlpha/entity"
+	"github.com/samber/lo"
+)
+
+// Watcher 在两次定时决策之间按固定间隔检查行情与持仓，条件成立时通过 Events 请求提前开始决策周期。
+//
+// 去抖:
+//   - 每个条件 (币种 + 类别) 在两次决策之间最多触发一次，上次决策时已经成立的条件 (例如已经接近止损) 不会触发
+//   - 上次决策后 cooldown 内不检查
+//   - 触发后直到下一次决策周期调用 Reset 之前不再检查
+//
+// Watcher 只负责发出请求，决策周期由主循环依次执行，同一时间只会有一个决策周期。
+type Watcher struct {
+	interval time.Duration
+	cooldown time.Duration
+	rules    Rules
+	market   collector.MarketQuerier
+	events   chan []Event
+
+	mu         sync.Mutex
+	generation int // 每次 Reset 加一，检查期间发生 Reset 时丢弃本次结果
+	coins      map[string]coinBaseline
+	positions  []entity.PositionData
+	resetAt    time.Time
+	fired      map[string]bool // key: coin + "/" + kind
+	pending    bool            // 已经发出请求，等待决策周期 Reset
+}
+
+func NewWatcher(interval, cooldown time.Duration, rules Rules, market collector.MarketQuerier) *Watcher {
+	return &Watcher{
+		interval: interval,
+		cooldown: cooldown,
+		rules:    rules,
+		market:   market,
+		events:   make(chan []Event, 1),
+	}
+}
+
+// Events 返回提前开始决策周期的请求，每个请求包含本次成立的全部条件
+func (w *Watcher) Events() <-chan []Event {
+	return w.events
+}
+
+// Reset 以决策周期看到的行情与持仓 (已合并止盈止损) 作为新的基准，每个决策周期采集数据后调用
+func (w *Watcher) Reset(data entity.PromptData) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.generation++
+	w.coins = make(map[string]coinBaseline, len(data.Coins))
+	for coin, coinData := range data.Coins {
+		w.coins[coin] = newCoinBaseline(coinData)
+	}
+	w.positions = slices.Clone(data.Positions)
+	w.resetAt = time.Now()
+	w.pending = false
+	// 这次决策之前发出、尚未被处理的请求已经过时
+	select {
+	case <-w.events:
+	default:
+	}
+
+	// 决策时已经成立的条件已经被这次决策看到，视为已触发
+	w.fired = make(map[string]bool)
+	for coin, base := range w.coins {
+		current := collector.Ticker{Price: base.price, FundingRate: base.funding, OpenInterest: base.oi}
+		for _, event := range w.rules.detect(coin, base, w.positions, current) {
+			w.fired[event.Coin+"/"+event.Kind] = true
+		}
+	}
+}
+
+// Run 按间隔检查直到 ctx 结束
+func (w *Watcher) Run(ctx context.Context) {
+	log.Printf("... ⚡ 事件触发: 每 %s 检查一次，两次决策至少间隔 %s", w.interval, w.cooldown)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+func (w *Watcher) check(ctx context.Context) {
+	w.mu.Lock()
+	if w.coins == nil || w.pending || time.Since(w.resetAt) < w.cooldown {
+		w.mu.Unlock()
+		return
+	}
+	generation, coins, positions := w.generation, w.coins, w.positions
+	w.mu.Unlock()
+
+	var events []Event
+	for _, coin := range slices.Sorted(maps.Keys(coins)) {
+		ticker, err := w.market.Ticker(ctx, coin)
+		if err != nil {
+			log.Printf("⚠️ [事件触发] 无法获取 %s 的行情: %v", coin, err)
+			continue
+		}
+		events = append(events, w.rules.detect(coin, coins[coin], positions, ticker)...)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if generation != w.generation {
+		return // 检查期间已经开始了新的决策周期
+	}
+	events = lo.Filter(events, func(e Event, _ int) bool { return !w.fired[e.Coin+"/"+e.Kind] })
+	if len(events) == 0 {
+		return
+	}
+	for _, e := range events {
+		w.fired[e.Coin+"/"+e.Kind] = true
+	}
+	w.pending = true
+	w.events <- events // pending 与 Reset 时的清空保证缓冲中最多只有一个请求，不会阻塞
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var clock atomic.Pointer[func() time.Time]
+
+// Now 返回当前时间。
+// 实盘下等同于 time.Now；回测时由模拟时钟接管，使持仓时长等计算与历史时间线一致
+func Now() time.Time {
+	if fn := clock.Load(); fn != nil {
+		return (*fn)()
+	}
+	return time.Now()
+}
+
+// SetClock 替换全局时钟，传入 nil 恢复为系统时钟
+func SetClock(now func() time.Time) {
+	if now == nil {
+		clock.Store(nil)
+		return
+	}
+	clock.Store(&now)
+}
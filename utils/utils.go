@@ -14,6 +14,9 @@ import (
 )
 
 func ParseResult[T any](completion *openai.ChatCompletion) (T, error) {
+	if len(completion.Choices) == 0 {
+		return lo.Empty[T](), fmt.Errorf("completion has no choices")
+	}
 	responseContent := completion.Choices[0].Message.Content
 	repaired, err := jsonrepair.JSONRepair(responseContent)
 	if err != nil {
@@ -88,3 +91,44 @@ func StdDev(data []float64) float64 {
 	variance := sumOfSquares / float64(len(data)-1)
 	return math.Sqrt(variance)
 }
+
+// SharpeRatio 根据账户价值序列计算 (未年化的) 夏普比率
+// 无风险利率按 0 处理，这在短周期交易中很常见
+func SharpeRatio(values []float64) float64 {
+	// 至少需要3个数据点才能计算2个回报率，从而计算标准差
+	if len(values) < 3 {
+		return 0.0
+	}
+
+	// 1. 计算回报率序列
+	// (P1-P0)/P0, (P2-P1)/P1, ...
+	returns := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == 0 { // 避免除以零
+			returns[i-1] = 0.0
+			continue
+		}
+		returns[i-1] = (values[i] - values[i-1]) / values[i-1]
+	}
+
+	// 2. Sharpe = (Average Return - Risk-Free Rate) / Standard Deviation
+	stdDevReturn := StdDev(returns)
+	if stdDevReturn == 0 {
+		return 0.0 // 避免除以零
+	}
+	return Avg(returns) / stdDevReturn
+}
+
+// MaxDrawdown 返回账户价值序列的最大回撤 (峰值到谷值的跌幅比例, 0-1)
+func MaxDrawdown(values []float64) float64 {
+	peak, maxDD := 0.0, 0.0
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+		if peak > 0 {
+			maxDD = math.Max(maxDD, (peak-v)/peak)
+		}
+	}
+	return maxDD
+}